@@ -0,0 +1,307 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const (
+	AttachCallback = "attach"
+	FilesCallback  = "files"
+)
+
+// attachSkip Аргумент кнопки, отказывающей от вложения
+const attachSkip = "skip"
+
+// attachmentsPageSize Сколько последних вложений отправлять по кнопке на карточке
+const attachmentsPageSize = 10
+
+// errConversationBusy у пользователя уже идёт другой диалог с ботом
+var errConversationBusy = errors.New("another conversation is in progress")
+
+// messageAttachment достаёт из сообщения фото или документ
+func messageAttachment(msg *tgbotapi.Message) (entity.Attachment, bool) {
+	attachment := entity.Attachment{Caption: msg.Caption}
+	if msg.From != nil {
+		attachment.UserID = msg.From.ID
+	}
+
+	switch {
+	case len(msg.Photo) > 0:
+		// Telegram присылает несколько размеров фото, последний самый крупный
+		attachment.Kind = entity.AttachmentPhoto
+		attachment.FileID = msg.Photo[len(msg.Photo)-1].FileID
+	case msg.Document != nil:
+		attachment.Kind = entity.AttachmentDocument
+		attachment.FileID = msg.Document.FileID
+		attachment.FileName = msg.Document.FileName
+	default:
+		return entity.Attachment{}, false
+	}
+	return attachment, true
+}
+
+// attachmentsCount число вложений для карточки. Ошибка чтения не мешает показать карточку
+func (b *Botik) attachmentsCount(taskID int64) int {
+	count, err := b.attachmentRepo.Count(b.ctx, taskID)
+	if err != nil {
+		slog.Error("failed to count attachments", slog.Int64("task_id", taskID), slog.String("error", err.Error()))
+	}
+	return count
+}
+
+// attachmentButtons кнопки вложений: показать приложенные файлы, если они есть, и приложить новый, если canAttach
+func attachmentButtons(taskID int64, count int, canAttach bool) []tgbotapi.InlineKeyboardButton {
+	id := strconv.FormatInt(taskID, 10)
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	if count > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf(lang.ButtonAttachments, count), callbackData(FilesCallback, id),
+		))
+	}
+	if canAttach {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(lang.ButtonAttach, callbackData(AttachCallback, id)))
+	}
+	return buttons
+}
+
+// askAttachment просит пользователя прислать фото или документ к заданию.
+// proof отмечает подтверждение выполнения, которое исполнитель присылает после отметки о выполнении
+func (b *Botik) askAttachment(task *entity.Task, userID int64, msgID int, proof bool) error {
+	conv, err := b.convRepo.Get(b.ctx, task.ChatID, userID)
+	switch {
+	case err == nil && conv.State != stateWaitingAttachment:
+		return errConversationBusy
+	case err != nil && !errors.Is(err, repository.ErrConversationNotFound):
+		return fmt.Errorf("get conversation: %w", err)
+	}
+
+	conv = entity.NewConversation(task.ChatID, userID, stateWaitingAttachment)
+	conv.Data[keyTaskID] = strconv.FormatInt(task.ID, 10)
+	question := fmt.Sprintf(lang.AskAttachment, task.ID)
+	if proof {
+		conv.Data[keyProof] = "1"
+		question = fmt.Sprintf(lang.AskProof, task.ID)
+	}
+
+	if err := b.convRepo.Save(b.ctx, conv); err != nil {
+		return fmt.Errorf("save conversation: %w", err)
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(lang.ButtonSkip, callbackData(AttachCallback, conv.Data[keyTaskID], attachSkip)),
+	))
+	return b.sendText(task.ChatID, question, WithReply(msgID), WithKeyboard(markup))
+}
+
+// attachCallback по кнопке "attach:<id>" ждёт файл к заданию, по "attach:<id>:skip" перестаёт ждать
+func (b *Botik) attachCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	chatID := cb.Message.Chat.ID
+
+	if len(args) > 1 && args[1] == attachSkip {
+		conv, err := b.convRepo.Get(b.ctx, chatID, cb.From.ID)
+		if errors.Is(err, repository.ErrConversationNotFound) || (err == nil && conv.Data[keyTaskID] != args[0]) {
+			return lang.NothingToCancel, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("get conversation: %w", err)
+		}
+
+		if err := b.convRepo.Delete(b.ctx, chatID, cb.From.ID); err != nil {
+			return "", fmt.Errorf("delete conversation: %w", err)
+		}
+
+		edit := tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, lang.AttachmentSkipped)
+		if _, err := b.bot.Send(edit); err != nil {
+			slog.Error("failed to edit message", slog.String("error", err.Error()))
+		}
+		return "", nil
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, id)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	allowed, err := b.canWorkOnTask(chatID, cb.From.ID, task)
+	if err != nil {
+		return "", fmt.Errorf("check permissions: %w", err)
+	}
+	if !allowed {
+		return lang.Forbidden, nil
+	}
+
+	err = b.askAttachment(task, cb.From.ID, cb.Message.MessageID, false)
+	if errors.Is(err, errConversationBusy) {
+		return lang.ConversationInProgress, nil
+	}
+	return "", err
+}
+
+// handleAttachmentMessage сохраняет фото или документ, которые ждёт диалог или которые прислали ответом на карточку.
+// Возвращает false, если файл не относится к заданию
+func (b *Botik) handleAttachmentMessage(msg *tgbotapi.Message) bool {
+	attachment, ok := messageAttachment(msg)
+	if !ok {
+		return false
+	}
+
+	chatID := msg.Chat.ID
+
+	conv, err := b.convRepo.Get(b.ctx, chatID, msg.From.ID)
+	switch {
+	case err == nil && conv.State == stateWaitingAttachment:
+		taskID, err := strconv.ParseInt(conv.Data[keyTaskID], 10, 64)
+		if err != nil {
+			slog.Error("malformed attachment conversation", slog.String("error", err.Error()))
+			return false
+		}
+
+		if err := b.convRepo.Delete(b.ctx, chatID, msg.From.ID); err != nil {
+			slog.Error("failed to delete conversation", slog.String("error", err.Error()))
+		}
+
+		attachment.TaskID = taskID
+		attachment.Proof = conv.Data[keyProof] != ""
+		b.saveAttachment(msg, attachment)
+		return true
+	case err != nil && !errors.Is(err, repository.ErrConversationNotFound):
+		slog.Error("failed to get conversation", slog.String("error", err.Error()))
+	}
+
+	if msg.ReplyToMessage == nil || msg.ReplyToMessage.From == nil || msg.ReplyToMessage.From.ID != b.bot.Self.ID {
+		return false
+	}
+
+	taskID, err := b.commentRepo.TaskByMessage(b.ctx, chatID, msg.ReplyToMessage.MessageID)
+	if errors.Is(err, repository.ErrMessageNotLinked) {
+		return false
+	}
+	if err != nil {
+		slog.Error("failed to find task by message", slog.String("error", err.Error()))
+		return false
+	}
+
+	attachment.TaskID = taskID
+	b.saveAttachment(msg, attachment)
+	return true
+}
+
+// saveAttachment проверяет права на задание, сохраняет вложение и показывает обновлённую карточку
+func (b *Botik) saveAttachment(msg *tgbotapi.Message, attachment entity.Attachment) {
+	chatID := msg.Chat.ID
+	fail := func(err error) {
+		slog.Error("handle task attachment", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msg.MessageID)
+	}
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msg.MessageID)); err != nil {
+			slog.Error("handle task attachment", slog.String("error", err.Error()))
+		}
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, attachment.TaskID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if task == nil {
+		reply(lang.TaskNotFound)
+		return
+	}
+
+	allowed, err := b.canWorkOnTask(chatID, msg.From.ID, task)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if !allowed {
+		reply(lang.Forbidden)
+		return
+	}
+
+	if err := b.attachmentRepo.Add(b.ctx, &attachment); err != nil {
+		fail(err)
+		return
+	}
+
+	// Новая карточка под файлом, чтобы автор сразу мог принять задание
+	header := fmt.Sprintf(lang.AttachmentSaved, task.ID)
+	if attachment.Proof {
+		header = fmt.Sprintf(lang.ProofSaved, task.ID)
+	}
+	if err := b.sendTaskCard(task, msg.From.ID, header, msg.MessageID); err != nil {
+		slog.Error("handle task attachment", slog.String("error", err.Error()))
+	}
+}
+
+// filesCallback отправляет в чат файлы, приложенные к заданию, по кнопке "files:<id>"
+func (b *Botik) filesCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	chatID := cb.Message.Chat.ID
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, id)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	attachments, err := b.attachmentRepo.List(b.ctx, task.ID, attachmentsPageSize)
+	if err != nil {
+		return "", fmt.Errorf("list attachments: %w", err)
+	}
+	if len(attachments) == 0 {
+		return lang.NoAttachments, nil
+	}
+
+	for _, attachment := range attachments {
+		caption := attachment.Caption
+		if attachment.Proof {
+			caption = fmt.Sprintf(lang.ProofCaption, task.ID, caption)
+		}
+
+		var msg tgbotapi.Chattable
+		if attachment.Kind == entity.AttachmentPhoto {
+			photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(attachment.FileID))
+			photo.Caption = caption
+			photo.ReplyToMessageID = cb.Message.MessageID
+			msg = photo
+		} else {
+			document := tgbotapi.NewDocument(chatID, tgbotapi.FileID(attachment.FileID))
+			document.Caption = caption
+			document.ReplyToMessageID = cb.Message.MessageID
+			msg = document
+		}
+
+		sent, err := b.bot.Send(msg)
+		if err != nil {
+			return "", fmt.Errorf("send attachment: %w", err)
+		}
+		// Ответ на присланный файл тоже относится к заданию
+		b.linkTaskMessage(task.ID, chatID, sent.MessageID)
+	}
+
+	return fmt.Sprintf(lang.AttachmentsSent, len(attachments)), nil
+}
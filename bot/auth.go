@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/repository"
+)
+
+// ErrForbidden роль пользователя не позволяет выполнить операцию
+var ErrForbidden = errors.New("operation is not permitted for user role")
+
+// Операции, которые проверяются перед выполнением команд. Команды без записи доступны всем
+var commandPermissions = map[string]entity.Action{
+	NewCommand:          entity.ActionCreate,
+	TaskCommand:         entity.ActionView,
+	TasksCommand:        entity.ActionView,
+	InitChatCommand:     entity.ActionManage,
+	RoleCommand:         entity.ActionManage,
+	TimezoneCommand:     entity.ActionManage,
+	RepeatCommand:       entity.ActionCreate,
+	SeriesCommand:       entity.ActionView,
+	BalanceCommand:      entity.ActionView,
+	TopCommand:          entity.ActionView,
+	AdjustCommand:       entity.ActionAdjust,
+	CurrencyCommand:     entity.ActionManage,
+	ShopCommand:         entity.ActionView,
+	PrizeAddCommand:     entity.ActionManage,
+	PrizeRmCommand:      entity.ActionManage,
+	OrdersCommand:       entity.ActionManage,
+	CheckCommand:        entity.ActionCreate,
+	CheckRemoveCommand:  entity.ActionCreate,
+	AutoDoneCommand:     entity.ActionCreate,
+	LinkAssigneeCommand: entity.ActionManage,
+	AdoptTasksCommand:   entity.ActionManage,
+}
+
+// authorize проверяет, что роль пользователя в чате допускает операцию
+func (b *Botik) authorize(chatID, userID int64, perm entity.Action) error {
+	role, err := b.roleOf(chatID, userID)
+	if err != nil {
+		return fmt.Errorf("get user role: %w", err)
+	}
+
+	if !b.policy.Allows(perm, role) {
+		slog.Warn(
+			"operation forbidden",
+			slog.Int64("chat_id", chatID),
+			slog.Int64("user_id", userID),
+			slog.String("action", string(perm)),
+			slog.String("role", string(role)),
+		)
+		return ErrForbidden
+	}
+	return nil
+}
+
+// actor возвращает пользователя вместе с его ролью в чате
+func (b *Botik) actor(chatID, userID int64) (entity.Actor, error) {
+	role, err := b.roleOf(chatID, userID)
+	if err != nil {
+		return entity.Actor{}, err
+	}
+	return entity.Actor{UserID: userID, Role: role}, nil
+}
+
+// roleOf возвращает роль пользователя в чате. Если бот ещё не знает об участнике,
+// роль определяется по его статусу в Telegram и сохраняется для инициализированного чата
+func (b *Botik) roleOf(chatID, userID int64) (entity.MemberRole, error) {
+	// В личной переписке с ботом пользователь распоряжается чатом сам
+	if chatID == userID {
+		return entity.RoleOwner, nil
+	}
+
+	member, err := b.chatRepo.GetMember(b.ctx, chatID, userID)
+	if err == nil && member.Active {
+		return member.Role, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrMemberNotFound) {
+		return "", err
+	}
+
+	tgMember, err := b.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("get chat member from telegram: %w", err)
+	}
+
+	role, ok := memberRole(tgMember)
+	if !ok {
+		return entity.RoleViewer, nil
+	}
+
+	b.memberJoined(chatID, tgMember.User, role)
+
+	// Вернувшийся участник сохраняет роль, назначенную ему раньше
+	if member, err := b.chatRepo.GetMember(b.ctx, chatID, userID); err == nil && member.Active {
+		return member.Role, nil
+	}
+	return role, nil
+}
@@ -1,44 +1,222 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/qrave1/task-track/config"
+	"github.com/qrave1/task-track/entity"
 	"github.com/qrave1/task-track/repository"
 )
 
+// Типы обновлений, которые бот получает от Telegram. chat_member не присылается без явной подписки
+var allowedUpdates = []string{
+	tgbotapi.UpdateTypeMessage,
+	tgbotapi.UpdateTypeCallbackQuery,
+	tgbotapi.UpdateTypeChatMember,
+	tgbotapi.UpdateTypeMyChatMember,
+}
+
 type Botik struct {
+	cfg      *config.Config
 	bot      *tgbotapi.BotAPI
 	taskRepo repository.TaskRepository
 	chatRepo repository.ChatRepository
+	convRepo repository.ConversationRepository
+	userRepo repository.UserRepository
+
+	location       *time.Location // Часовой пояс чатов, для которых он не задан командой /timezone
+	seriesRepo     repository.SeriesRepository
+	ledgerRepo     repository.LedgerRepository
+	shopRepo       repository.ShopRepository
+	checklistRepo  repository.ChecklistRepository
+	commentRepo    repository.CommentRepository
+	attachmentRepo repository.AttachmentRepository
+	taskListRepo   repository.TaskListRepository
+
+	scheduler  *scheduler
+	policy     entity.Policy
+	callbacks  *callbackRouter
+	dispatcher *dispatcher
 
 	updates tgbotapi.UpdatesChannel
+	server  *http.Server // HTTP сервер вебхука, nil в режиме polling
+
+	outbox     chan tgbotapi.Chattable // Очередь уведомлений, отправляемых в фоне
+	outboxDone chan struct{}
+
+	// ctx передаётся в обработчики и отменяется, если они не успели завершиться при остановке
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{} // Закрывается, когда бот перестаёт принимать новые обновления
+	done   chan struct{} // Закрывается, когда все воркеры завершили обработку
+
+	// Stop может быть вызван повторно, например по сигналу во время остановки из-за ошибки
+	stopOnce   sync.Once
+	outboxOnce sync.Once
+
+	schedulerDone chan struct{} // Закрывается, когда планировщик фоновых работ остановлен
 }
 
-func NewBotik(cfg *config.Config, taskRepo repository.TaskRepository, chatRepo repository.ChatRepository) (*Botik, error) {
-	bot, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
+func NewBotik(
+	cfg *config.Config,
+	taskRepo repository.TaskRepository,
+	chatRepo repository.ChatRepository,
+	convRepo repository.ConversationRepository,
+	userRepo repository.UserRepository,
+	reminderRepo repository.ReminderRepository,
+	seriesRepo repository.SeriesRepository,
+	ledgerRepo repository.LedgerRepository,
+	shopRepo repository.ShopRepository,
+	checklistRepo repository.ChecklistRepository,
+	commentRepo repository.CommentRepository,
+	attachmentRepo repository.AttachmentRepository,
+	taskListRepo repository.TaskListRepository,
+) (*Botik, error) {
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(cfg.Telegram.Token, cfg.Telegram.APIEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 
+	policy, err := entity.NewPolicy(cfg.Permissions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid permissions: %w", err)
+	}
+
+	location, err := loadTimeZone(cfg.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone: %w", err)
+	}
+
 	bot.Debug = cfg.Debug
 	slog.Info("Authorized on account", "username", bot.Self.UserName)
 
-	return &Botik{
-		bot:      bot,
-		taskRepo: taskRepo,
-		chatRepo: chatRepo,
-		updates:  nil,
-	}, nil
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := &Botik{
+		cfg:        cfg,
+		bot:        bot,
+		taskRepo:   taskRepo,
+		chatRepo:   chatRepo,
+		convRepo:   convRepo,
+		userRepo:   userRepo,
+		location:   location,
+		policy:     policy,
+		updates:    nil,
+		outbox:     make(chan tgbotapi.Chattable, outboxSize),
+		outboxDone: make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+
+		seriesRepo:     seriesRepo,
+		ledgerRepo:     ledgerRepo,
+		shopRepo:       shopRepo,
+		checklistRepo:  checklistRepo,
+		commentRepo:    commentRepo,
+		attachmentRepo: attachmentRepo,
+		taskListRepo:   taskListRepo,
+
+		schedulerDone: make(chan struct{}),
+	}
+	b.callbacks = newCallbackRouter(b.authorize)
+	b.dispatcher = newDispatcher(cfg.Workers, cfg.QueueSize, b.handleUpdate)
+	b.registerCallbacks()
+
+	reminders, err := newReminderJob(cfg.Reminders.Offsets, taskRepo, reminderRepo, b.sendReminder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reminder settings: %w", err)
+	}
+
+	b.scheduler, err = newScheduler(systemClock{}, cfg.SchedulerInterval, reminders.run, b.advanceAllSeries)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduler settings: %w", err)
+	}
+
+	return b, nil
+}
+
+// Start начинает получение обновлений: через вебхук, если задан его URL, иначе через long polling
+func (b *Botik) Start() error {
+	if b.cfg.Telegram.Webhook.URL != "" {
+		slog.Info("Starting in webhook mode", slog.Int("port", b.cfg.Telegram.Webhook.Port))
+		if err := b.startWebhook(); err != nil {
+			return err
+		}
+	} else {
+		slog.Info("Starting in polling mode")
+		if err := b.startPolling(); err != nil {
+			return err
+		}
+	}
+
+	go b.handleUpdates()
+	go b.sendQueued()
+	go func() {
+		defer close(b.schedulerDone)
+		b.scheduler.run(b.ctx, b.stop)
+	}()
+	return nil
+}
+
+// Stop прекращает приём обновлений, дожидается завершения уже начатых обработчиков
+// и отправки накопленных уведомлений. Если ctx истекает раньше, контекст обработчиков отменяется.
+// Повторный вызов не начинает остановку заново, а дожидается её завершения
+func (b *Botik) Stop(ctx context.Context) error {
+	defer b.cancel()
+
+	b.stopOnce.Do(func() {
+		close(b.stop)
+
+		if b.server != nil {
+			if err := b.server.Shutdown(ctx); err != nil {
+				slog.Error("failed to shutdown webhook server", slog.String("error", err.Error()))
+			}
+		} else {
+			b.bot.StopReceivingUpdates()
+		}
+	})
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for update handlers: %w", ctx.Err())
+	}
+
+	select {
+	case <-b.schedulerDone:
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for scheduler: %w", ctx.Err())
+	}
+
+	// Обработчики завершены, новых уведомлений в очереди не появится
+	b.outboxOnce.Do(func() { close(b.outbox) })
+
+	select {
+	case <-b.outboxDone:
+	case <-ctx.Done():
+		return fmt.Errorf("flushing outgoing messages: %w", ctx.Err())
+	}
+
+	return nil
 }
 
-func (b *Botik) Start() {
-	slog.Info("Starting in debug mode (polling)")
+func (b *Botik) startPolling() error {
+	// Пока вебхук зарегистрирован, Telegram отклоняет getUpdates
+	if _, err := b.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
+	u.AllowedUpdates = allowedUpdates
 	b.updates = b.bot.GetUpdatesChan(u)
 
-	go b.handleUpdates()
+	return nil
 }
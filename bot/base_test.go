@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStopCanBeCalledAgain(t *testing.T) {
+	b, _, _, _ := startWebhookBot(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Сигнал может прийти, пока идёт остановка из-за ошибки
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- b.Stop(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Stop() error: %v", err)
+		}
+	}
+
+	// И после завершения остановки
+	if err := b.Stop(ctx); err != nil {
+		t.Errorf("repeated Stop() error: %v", err)
+	}
+}
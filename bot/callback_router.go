@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+)
+
+// Payload кнопки имеет вид "действие" или "действие:арг1:арг2"
+const callbackSeparator = ":"
+
+var (
+	ErrMalformedCallback = errors.New("malformed callback data")
+	ErrUnknownCallback   = errors.New("unknown callback action")
+)
+
+// callbackHandler обрабатывает нажатие inline-кнопки. В args передаются части payload после действия.
+// Возвращённый текст показывается пользователю во всплывающем уведомлении
+type callbackHandler func(cb *tgbotapi.CallbackQuery, args []string) (string, error)
+
+// authorizer проверяет, что пользователю разрешена операция в чате
+type authorizer func(chatID, userID int64, perm entity.Action) error
+
+type callbackRoute struct {
+	perm    entity.Action // Пустая операция не требует проверки прав
+	handler callbackHandler
+}
+
+// callbackRouter направляет нажатия inline-кнопок обработчикам, зарегистрированным по действию,
+// предварительно проверяя права пользователя
+type callbackRouter struct {
+	routes    map[string]callbackRoute
+	authorize authorizer
+}
+
+func newCallbackRouter(authorize authorizer) *callbackRouter {
+	return &callbackRouter{
+		routes:    make(map[string]callbackRoute),
+		authorize: authorize,
+	}
+}
+
+// Handle регистрирует обработчик действия, доступного при наличии прав на операцию perm.
+// Повторная регистрация заменяет прежний обработчик
+func (r *callbackRouter) Handle(action string, perm entity.Action, h callbackHandler) {
+	r.routes[action] = callbackRoute{perm: perm, handler: h}
+}
+
+// route разбирает payload и вызывает обработчик соответствующего действия
+func (r *callbackRouter) route(cb *tgbotapi.CallbackQuery) (string, error) {
+	if cb.Message == nil || cb.From == nil {
+		return "", ErrMalformedCallback
+	}
+
+	parts := strings.Split(cb.Data, callbackSeparator)
+	for _, part := range parts {
+		if part == "" {
+			return "", ErrMalformedCallback
+		}
+	}
+
+	route, ok := r.routes[parts[0]]
+	if !ok {
+		return "", ErrUnknownCallback
+	}
+
+	if route.perm != "" {
+		if err := r.authorize(cb.Message.Chat.ID, cb.From.ID, route.perm); err != nil {
+			return "", err
+		}
+	}
+
+	return route.handler(cb, parts[1:])
+}
+
+// callbackData собирает payload кнопки из действия и аргументов
+func callbackData(action string, args ...string) string {
+	return strings.Join(append([]string{action}, args...), callbackSeparator)
+}
+
+// int64Arg разбирает числовой аргумент payload с индексом i
+func int64Arg(args []string, i int) (int64, error) {
+	if i >= len(args) {
+		return 0, ErrMalformedCallback
+	}
+
+	v, err := strconv.ParseInt(args[i], 10, 64)
+	if err != nil {
+		return 0, ErrMalformedCallback
+	}
+	return v, nil
+}
@@ -0,0 +1,294 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const ChecklistCallback = "check"
+
+// checklistButtonLen Максимальная длина текста пункта на кнопке
+const checklistButtonLen = 32
+
+// checklistText строки чек-листа для карточки задания в разметке HTML
+func checklistText(task *entity.Task, checklist entity.Checklist) string {
+	if len(checklist) == 0 {
+		return ""
+	}
+
+	done, total := checklist.Progress()
+	header := fmt.Sprintf(lang.ChecklistProgress, done, total)
+	if task.AutoComplete {
+		header += lang.ChecklistAutoComplete
+	}
+
+	lines := []string{header}
+	for _, item := range checklist {
+		lines = append(lines, fmt.Sprintf(lang.ChecklistItem, checkMark(item), item.Position, html.EscapeString(item.Text)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func checkMark(item entity.ChecklistItem) string {
+	if item.Done {
+		return lang.ChecklistChecked
+	}
+	return lang.ChecklistUnchecked
+}
+
+// checklistButtons кнопки переключения пунктов, пока задание не выполнено
+func checklistButtons(task *entity.Task, checklist entity.Checklist) [][]tgbotapi.InlineKeyboardButton {
+	if !task.IsActive() {
+		return nil
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(checklist))
+	for _, item := range checklist {
+		text := []rune(item.Text)
+		if len(text) > checklistButtonLen {
+			text = append(text[:checklistButtonLen-1], '…')
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf(lang.ChecklistItem, checkMark(item), item.Position, string(text)),
+			callbackData(ChecklistCallback, strconv.FormatInt(task.ID, 10), strconv.FormatInt(item.ID, 10)),
+		)))
+	}
+	return rows
+}
+
+// taskChecklist чек-лист задания для карточки. Ошибка чтения не мешает показать карточку
+func (b *Botik) taskChecklist(task *entity.Task) entity.Checklist {
+	checklist, err := b.checklistRepo.List(b.ctx, task.ID)
+	if err != nil {
+		slog.Error("failed to get checklist", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+	}
+	return checklist
+}
+
+// manageableTask находит задание из аргументов команды и проверяет, что пользователь может его менять.
+// При ошибке сам отвечает пользователю и возвращает nil
+func (b *Botik) manageableTask(chatID, userID int64, msgID int, rawID, usage, command string) *entity.Task {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /"+command+" command", slog.String("error", err.Error()))
+		}
+	}
+	fail := func(err error) {
+		slog.Error("handle /"+command+" command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+	}
+
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		reply(usage)
+		return nil
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, id)
+	if err != nil {
+		fail(err)
+		return nil
+	}
+	if task == nil {
+		reply(lang.TaskNotFound)
+		return nil
+	}
+
+	allowed, err := b.canManageTask(chatID, userID, task.CreatedBy)
+	if err != nil {
+		fail(err)
+		return nil
+	}
+	if !allowed {
+		reply(lang.Forbidden)
+		return nil
+	}
+	return task
+}
+
+// CheckCmd добавляет пункты в чек-лист задания: /check <номер> <пункт>, каждый пункт с новой строки
+func (b *Botik) CheckCmd(chatID, userID int64, msgID int, args string) {
+	rawID, rawItems, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if strings.TrimSpace(rawItems) == "" {
+		// Пункты могут начинаться со следующей строки после номера
+		rawID, rawItems, _ = strings.Cut(strings.TrimSpace(args), "\n")
+	}
+
+	var items []string
+	for _, line := range strings.Split(rawItems, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+	if len(items) == 0 {
+		if err := b.sendText(chatID, lang.CheckUsage, WithReply(msgID)); err != nil {
+			slog.Error("handle /check command", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	task := b.manageableTask(chatID, userID, msgID, strings.TrimSpace(rawID), lang.CheckUsage, CheckCommand)
+	if task == nil {
+		return
+	}
+
+	for _, text := range items {
+		if err := b.checklistRepo.Add(b.ctx, &entity.ChecklistItem{TaskID: task.ID, Text: text}); err != nil {
+			slog.Error("handle /check command", slog.String("error", err.Error()))
+			b.sendFailedStub(chatID, msgID)
+			return
+		}
+	}
+
+	if err := b.sendTaskCard(task, userID, fmt.Sprintf(lang.ChecklistItemsAdded, len(items)), msgID); err != nil {
+		slog.Error("handle /check command", slog.String("error", err.Error()))
+	}
+}
+
+// CheckRemoveCmd удаляет пункт из чек-листа: /check_remove <номер задания> <номер пункта>
+func (b *Botik) CheckRemoveCmd(chatID, userID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /check_remove command", slog.String("error", err.Error()))
+		}
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		reply(lang.CheckRemoveUsage)
+		return
+	}
+
+	position, err := strconv.Atoi(fields[1])
+	if err != nil {
+		reply(lang.CheckRemoveUsage)
+		return
+	}
+
+	task := b.manageableTask(chatID, userID, msgID, fields[0], lang.CheckRemoveUsage, CheckRemoveCommand)
+	if task == nil {
+		return
+	}
+
+	err = b.checklistRepo.Remove(b.ctx, task.ID, position)
+	if errors.Is(err, repository.ErrChecklistItemNotFound) {
+		reply(lang.ChecklistItemNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("handle /check_remove command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	if err := b.sendTaskCard(task, userID, lang.ChecklistItemRemoved, msgID); err != nil {
+		slog.Error("handle /check_remove command", slog.String("error", err.Error()))
+	}
+}
+
+// AutoDoneCmd включает или выключает завершение задания по чек-листу: /autodone <номер>
+func (b *Botik) AutoDoneCmd(chatID, userID int64, msgID int, args string) {
+	task := b.manageableTask(chatID, userID, msgID, strings.TrimSpace(args), lang.AutoDoneUsage, AutoDoneCommand)
+	if task == nil {
+		return
+	}
+
+	task.AutoComplete = !task.AutoComplete
+	err := b.taskRepo.Update(b.ctx, task)
+	if errors.Is(err, repository.ErrTaskNotFound) || errors.Is(err, repository.ErrVersionConflict) {
+		text := lang.TaskEditConflict
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			text = lang.TaskNotFound
+		}
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /autodone command", slog.String("error", err.Error()))
+		}
+		return
+	}
+	if err != nil {
+		slog.Error("handle /autodone command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	header := lang.AutoDoneDisabled
+	if task.AutoComplete {
+		header = lang.AutoDoneEnabled
+	}
+	if err := b.sendTaskCard(task, userID, header, msgID); err != nil {
+		slog.Error("handle /autodone command", slog.String("error", err.Error()))
+	}
+}
+
+// checklistCallback отмечает пункт чек-листа по кнопке "check:<задание>:<пункт>".
+// Пункты отмечает исполнитель или тот, кто может менять задание
+func (b *Botik) checklistCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	taskID, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+	itemID, err := int64Arg(args, 1)
+	if err != nil {
+		return "", err
+	}
+
+	chatID := cb.Message.Chat.ID
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, taskID)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	allowed, err := b.canWorkOnTask(chatID, cb.From.ID, task)
+	if err != nil {
+		return "", fmt.Errorf("check permissions: %w", err)
+	}
+	if !allowed {
+		return lang.Forbidden, nil
+	}
+
+	if !task.IsActive() {
+		return lang.ChecklistLocked, b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+	}
+
+	item, err := b.checklistRepo.Toggle(b.ctx, task.ID, itemID, cb.From.ID)
+	if errors.Is(err, repository.ErrChecklistItemNotFound) {
+		return lang.ChecklistItemNotFound, b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("toggle checklist item: %w", err)
+	}
+
+	answer := checkMark(item)
+	if item.Done && task.AutoComplete && b.taskChecklist(task).Complete() {
+		err := b.changeStatus(task, entity.StatusDone, newEntityUser(cb.From), cb.Message.MessageID)
+		switch {
+		case err == nil:
+			answer = lang.ChecklistCompleted
+		case errors.Is(err, entity.ErrTransitionNotAllowed), errors.Is(err, entity.ErrActorNotAllowed):
+			// Выполненным задание отмечает только тот, кому это разрешено, остальным достаточно отметки пункта
+		case errors.Is(err, repository.ErrStatusConflict):
+			// Статус уже сменили вручную, карточку показываем по свежим данным
+			if fresh, err := b.taskRepo.GetByID(b.ctx, chatID, task.ID); err == nil && fresh != nil {
+				task = fresh
+			}
+		default:
+			slog.Error("failed to complete task by checklist", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+		}
+	}
+
+	return answer, b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+)
+
+const (
+	testChatID   = -100
+	testCreator  = 7
+	testAssignee = 8
+	testAdmin    = 9
+)
+
+// newChecklistTask создаёт задание с автовыполнением и чек-листом, в котором не отмечен один пункт
+func newChecklistTask(t *testing.T, b *Botik) (*entity.Task, entity.ChecklistItem) {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := b.chatRepo.Create(ctx, entity.NewChat(testChatID)); err != nil {
+		t.Fatal(err)
+	}
+	for userID, role := range map[int64]entity.MemberRole{
+		testCreator:  entity.RoleMember,
+		testAssignee: entity.RoleMember,
+		testAdmin:    entity.RoleAdmin,
+	} {
+		if err := b.chatRepo.SaveMember(ctx, entity.NewChatMember(testChatID, userID, role)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	task := &entity.Task{
+		ChatID: testChatID, Title: "Убрать кухню", Status: entity.StatusInProgress,
+		AssigneeID: testAssignee, AutoComplete: true, CreatedBy: testCreator,
+	}
+	if err := b.taskRepo.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+
+	last := entity.ChecklistItem{TaskID: task.ID, Text: "Помыть посуду"}
+	for _, item := range []*entity.ChecklistItem{{TaskID: task.ID, Text: "Протереть стол"}, &last} {
+		if err := b.checklistRepo.Add(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, err := b.checklistRepo.List(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.checklistRepo.Toggle(ctx, task.ID, first[0].ID, testAssignee); err != nil {
+		t.Fatal(err)
+	}
+
+	return task, last
+}
+
+// checkItem отмечает пункт чек-листа кнопкой от имени пользователя
+func checkItem(t *testing.T, b *Botik, task *entity.Task, item entity.ChecklistItem, userID int64) string {
+	t.Helper()
+
+	cb := &tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: userID, FirstName: "Петя"},
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: task.ChatID}},
+	}
+	answer, err := b.checklistCallback(cb, []string{strconv.FormatInt(task.ID, 10), strconv.FormatInt(item.ID, 10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return answer
+}
+
+// queued возвращает уведомления, поставленные в очередь отправки
+func queued(b *Botik) []tgbotapi.MessageConfig {
+	var msgs []tgbotapi.MessageConfig
+	for {
+		select {
+		case msg := <-b.outbox:
+			if m, ok := msg.(tgbotapi.MessageConfig); ok {
+				msgs = append(msgs, m)
+			}
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestChecklistCompletesTaskAsStatusChange(t *testing.T) {
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+	task, last := newChecklistTask(t, b)
+
+	if answer := checkItem(t, b, task, last, testAssignee); answer != lang.ChecklistCompleted {
+		t.Errorf("answer %q, want %q", answer, lang.ChecklistCompleted)
+	}
+
+	got, err := b.taskRepo.GetByID(context.Background(), task.ChatID, task.ID)
+	if err != nil || got == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.Status != entity.StatusDone {
+		t.Errorf("status %s, want %s", got.Status, entity.StatusDone)
+	}
+
+	// Исполнителю предлагается приложить подтверждение
+	conv, err := b.convRepo.Get(context.Background(), task.ChatID, testAssignee)
+	if err != nil || conv.State != stateWaitingAttachment || conv.Data[keyProof] != "1" {
+		t.Errorf("proof conversation %+v, %v", conv, err)
+	}
+
+	// Автор узнаёт о выполнении
+	msgs := queued(b)
+	if len(msgs) != 1 || msgs[0].ChatID != testCreator {
+		t.Errorf("queued notifications %+v, want one to creator", msgs)
+	}
+}
+
+func TestChecklistDoesNotCompleteForbiddenTransition(t *testing.T) {
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+	task, last := newChecklistTask(t, b)
+
+	// Администратор может отмечать пункты, но выполненным задание отмечает только исполнитель
+	if answer := checkItem(t, b, task, last, testAdmin); answer == lang.ChecklistCompleted {
+		t.Errorf("answer %q for admin", answer)
+	}
+
+	got, err := b.taskRepo.GetByID(context.Background(), task.ChatID, task.ID)
+	if err != nil || got == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.Status != entity.StatusInProgress {
+		t.Errorf("status %s, want %s", got.Status, entity.StatusInProgress)
+	}
+	if msgs := queued(b); len(msgs) != 0 {
+		t.Errorf("queued notifications %+v, want none", msgs)
+	}
+}
@@ -1,9 +1,10 @@
 package bot
 
 import (
-	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/qrave1/task-track/entity"
@@ -12,26 +13,92 @@ import (
 )
 
 const (
-	StartCommand    = "start"
-	HelpCommand     = "help"
-	NewCommand      = "new"
-	InitChatCommand = "init_chat"
+	StartCommand        = "start"
+	HelpCommand         = "help"
+	NewCommand          = "new"
+	TaskCommand         = "task"
+	TasksCommand        = "tasks"
+	InitChatCommand     = "init_chat"
+	RoleCommand         = "role"
+	TimezoneCommand     = "timezone"
+	RepeatCommand       = "repeat"
+	SeriesCommand       = "series"
+	BalanceCommand      = "balance"
+	TopCommand          = "top"
+	AdjustCommand       = "adjust"
+	CurrencyCommand     = "currency"
+	ShopCommand         = "shop"
+	PrizeAddCommand     = "prize_add"
+	PrizeRmCommand      = "prize_remove"
+	OrdersCommand       = "orders"
+	CheckCommand        = "check"
+	CheckRemoveCommand  = "check_remove"
+	AutoDoneCommand     = "autodone"
+	LinkAssigneeCommand = "link_assignee"
+	AdoptTasksCommand   = "adopt_tasks"
 )
 
 func (b *Botik) StartCmd(chatID int64, msgID int) {
-	if err := b.sendText(chatID, lang.Start, WithReply(msgID)); err != nil {
+	if err := b.sendText(chatID, lang.Start, WithReply(msgID), WithKeyboard(mainMenuKeyboard())); err != nil {
 		slog.Error("handle /start command", slog.String("error", err.Error()))
 	}
 }
 
+// menuCallback показывает главное меню по кнопке "menu", а по кнопке "menu:new" начинает создание задания
+func (b *Botik) menuCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	chatID := cb.Message.Chat.ID
+
+	if len(args) == 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, lang.MainMenu, mainMenuKeyboard())
+		if _, err := b.bot.Send(edit); err != nil {
+			return "", fmt.Errorf("edit main menu: %w", err)
+		}
+		return "", nil
+	}
+
+	if args[0] != menuNew {
+		return "", ErrMalformedCallback
+	}
+
+	// Меню видят все участники, а создавать задания может не каждый
+	if err := b.authorize(chatID, cb.From.ID, entity.ActionCreate); err != nil {
+		return "", err
+	}
+
+	b.NewCmd(chatID, cb.From.ID, cb.Message.MessageID)
+	return "", nil
+}
+
 func (b *Botik) HelpCmd(chatID int64, msgID int) {
 	if err := b.sendText(chatID, lang.Help, WithReply(msgID)); err != nil {
 		slog.Error("handle /help command", slog.String("error", err.Error()))
 	}
 }
 
-func (b *Botik) NewCmd(chatID int64, msgID int) {
+// NewCmd начинает пошаговый диалог создания задания
+func (b *Botik) NewCmd(chatID, userID int64, msgID int) {
+	// Исполнитель выбирается из участников, поэтому чат должен быть инициализирован
+	if _, err := b.chatRepo.GetByID(b.ctx, chatID); err != nil {
+		if errors.Is(err, repository.ErrChatNotFound) {
+			err = b.sendText(chatID, lang.ChatNotInitialized, WithReply(msgID))
+		}
+		if err != nil {
+			slog.Error("handle /new command", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	conv := entity.NewConversation(chatID, userID, stateWaitingTitle)
+
+	if err := b.convRepo.Save(b.ctx, conv); err != nil {
+		slog.Error("handle /new command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
 
+	if err := b.sendText(chatID, lang.AskTaskTitle, WithReply(msgID), WithKeyboard(cancelKeyboard())); err != nil {
+		slog.Error("handle /new command", slog.String("error", err.Error()))
+	}
 }
 
 func (b *Botik) initChatCmd(chatID int64, msgID int) {
@@ -45,7 +112,7 @@ func (b *Botik) initChatCmd(chatID int64, msgID int) {
 		}
 	}()
 
-	chat, err := b.chatRepo.GetByID(context.Background(), chatID)
+	chat, err := b.chatRepo.GetByID(b.ctx, chatID)
 	if err != nil {
 		if errors.Is(err, repository.ErrChatNotFound) {
 			chatMembers, err := b.bot.GetChatAdministrators(
@@ -54,24 +121,41 @@ func (b *Botik) initChatCmd(chatID int64, msgID int) {
 				},
 			)
 			if err != nil {
-				slog.Error("failed to get chat members: %v", err)
+				slog.Error("failed to get chat members", slog.String("error", err.Error()))
 				sentStub = true
 				return
 			}
 
-			var chatUsers []int64
-			for _, chatMember := range chatMembers {
-				chatUsers = append(chatUsers, chatMember.User.ID)
-			}
+			chat = entity.NewChat(chatID)
 
-			chat = entity.NewChat(chatID, chatUsers)
-
-			err = b.chatRepo.Create(context.Background(), chat)
+			err = b.chatRepo.Create(b.ctx, chat)
 			if err != nil {
 				slog.Error("failed to create chat", slog.String("error", err.Error()))
 				sentStub = true
 				return
 			}
+
+			for _, chatMember := range chatMembers {
+				if chatMember.User.IsBot {
+					continue
+				}
+
+				b.rememberUser(b.ctx, chatMember.User)
+
+				role, _ := memberRole(chatMember)
+				err = b.chatRepo.SaveMember(b.ctx, entity.NewChatMember(chatID, chatMember.User.ID, role))
+				if err != nil {
+					slog.Error("failed to save chat member", slog.String("error", err.Error()))
+					sentStub = true
+					return
+				}
+
+				b.linkLegacyAssignee(chatID, chatMember.User)
+			}
+
+			if err := b.sendText(chatID, lang.ChatInitialized, WithReply(msgID)); err != nil {
+				slog.Error(err.Error())
+			}
 		} else {
 			slog.Error("failed to get chat by ID", slog.String("error", err.Error()))
 			sentStub = true
@@ -79,3 +163,60 @@ func (b *Botik) initChatCmd(chatID int64, msgID int) {
 		}
 	}
 }
+
+// RoleCmd меняет роль участника: /role @username роль
+func (b *Botik) RoleCmd(chatID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /role command", slog.String("error", err.Error()))
+		}
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		reply(lang.RoleUsage)
+		return
+	}
+
+	role, ok := entity.ParseRole(fields[1])
+	if !ok || role == entity.RoleOwner {
+		reply(lang.RoleUsage)
+		return
+	}
+
+	user, err := b.userRepo.GetByUsername(b.ctx, fields[0])
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			reply(lang.UserNotFound)
+			return
+		}
+		slog.Error("handle /role command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	member, err := b.chatRepo.GetMember(b.ctx, chatID, user.ID)
+	if err != nil || !member.Active {
+		if err == nil || errors.Is(err, repository.ErrMemberNotFound) {
+			reply(lang.NotChatMember)
+			return
+		}
+		slog.Error("handle /role command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	// Владелец чата определяется Telegram и не может быть понижен
+	if member.Role == entity.RoleOwner {
+		reply(lang.OwnerRoleFixed)
+		return
+	}
+
+	if err := b.chatRepo.SetRole(b.ctx, chatID, user.ID, role); err != nil {
+		slog.Error("handle /role command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	reply(fmt.Sprintf(lang.RoleChanged, user.Mention(), role))
+}
@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const CommentsCallback = "comments"
+
+// commentsPageSize Сколько последних комментариев показывать под заданием
+const commentsPageSize = 10
+
+// linkTaskMessage запоминает сообщение бота о задании, чтобы ответы на него сохранялись как комментарии
+func (b *Botik) linkTaskMessage(taskID, chatID int64, msgID int) {
+	if err := b.commentRepo.LinkMessage(b.ctx, chatID, msgID, taskID); err != nil {
+		slog.Error("failed to link task message", slog.Int64("task_id", taskID), slog.String("error", err.Error()))
+	}
+}
+
+// commentsCount число комментариев для кнопки на карточке. Ошибка чтения не мешает показать карточку
+func (b *Botik) commentsCount(taskID int64) int {
+	count, err := b.commentRepo.Count(b.ctx, taskID)
+	if err != nil {
+		slog.Error("failed to count comments", slog.Int64("task_id", taskID), slog.String("error", err.Error()))
+	}
+	return count
+}
+
+// commentsButton кнопка перехода к комментариям задания
+func commentsButton(taskID int64, count int) tgbotapi.InlineKeyboardButton {
+	text := lang.ButtonComments
+	if count > 0 {
+		text = fmt.Sprintf(lang.ButtonCommentsCount, count)
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(text, callbackData(CommentsCallback, strconv.FormatInt(taskID, 10)))
+}
+
+// handleTaskReply сохраняет ответ на сообщение бота о задании как комментарий.
+// Возвращает false, если сообщение не относится к заданию и его нужно обработать дальше
+func (b *Botik) handleTaskReply(msg *tgbotapi.Message) bool {
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		return false
+	}
+
+	chatID := msg.Chat.ID
+
+	taskID, err := b.commentRepo.TaskByMessage(b.ctx, chatID, msg.ReplyToMessage.MessageID)
+	if errors.Is(err, repository.ErrMessageNotLinked) {
+		return false
+	}
+	if err != nil {
+		slog.Error("failed to find task by message", slog.String("error", err.Error()))
+		return false
+	}
+
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msg.MessageID)); err != nil {
+			slog.Error("handle task comment", slog.String("error", err.Error()))
+		}
+	}
+
+	err = b.authorize(chatID, msg.From.ID, entity.ActionComment)
+	if errors.Is(err, ErrForbidden) {
+		reply(lang.Forbidden)
+		return true
+	}
+	if err != nil {
+		slog.Error("handle task comment", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msg.MessageID)
+		return true
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, taskID)
+	if err != nil {
+		slog.Error("handle task comment", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msg.MessageID)
+		return true
+	}
+	if task == nil {
+		reply(lang.TaskNotFound)
+		return true
+	}
+
+	comment := &entity.Comment{
+		TaskID:    task.ID,
+		ChatID:    chatID,
+		MessageID: msg.MessageID,
+		UserID:    msg.From.ID,
+		Text:      text,
+	}
+	if err := b.commentRepo.Add(b.ctx, comment); err != nil {
+		slog.Error("handle task comment", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msg.MessageID)
+		return true
+	}
+
+	reply(fmt.Sprintf(lang.CommentSaved, task.ID))
+	b.notifyComment(task, comment, newEntityUser(msg.From))
+	return true
+}
+
+// notifyComment пишет исполнителю и автору задания о новом комментарии в личные сообщения
+func (b *Botik) notifyComment(task *entity.Task, comment *entity.Comment, author entity.User) {
+	text := fmt.Sprintf(lang.CommentNotification, author.DisplayName(), task.ID, task.Title, comment.Text)
+
+	notified := map[int64]bool{author.ID: true, 0: true}
+	for _, userID := range []int64{task.AssigneeID, task.CreatedBy} {
+		// В личном чате комментарий и так виден единственному участнику
+		if notified[userID] || userID == task.ChatID {
+			continue
+		}
+		notified[userID] = true
+
+		// Бот не может писать пользователю, который не начинал с ним диалог, ошибка отправки только логируется
+		b.enqueue(tgbotapi.NewMessage(userID, text))
+	}
+}
+
+// commentsText последние комментарии задания
+func (b *Botik) commentsText(task *entity.Task) (string, error) {
+	comments, err := b.commentRepo.List(b.ctx, task.ID, commentsPageSize)
+	if err != nil {
+		return "", err
+	}
+
+	header := fmt.Sprintf(lang.CommentsHeader, task.ID, task.Title)
+	if len(comments) == 0 {
+		return header + "\n\n" + lang.NoComments, nil
+	}
+
+	ids := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.UserID)
+	}
+	users, err := b.userRepo.GetByIDs(b.ctx, ids)
+	if err != nil {
+		slog.Error("failed to get users", slog.String("error", err.Error()))
+		users = map[int64]entity.User{}
+	}
+
+	loc := b.chatLocation(task.ChatID)
+	lines := []string{header}
+	for _, comment := range comments {
+		user, ok := users[comment.UserID]
+		if !ok {
+			user = entity.User{ID: comment.UserID}
+		}
+		lines = append(lines, fmt.Sprintf(
+			lang.CommentLine, user.DisplayName(), comment.CreatedAt.In(loc).Format(dueLayout), comment.Text,
+		))
+	}
+	return strings.Join(lines, "\n\n"), nil
+}
+
+// commentsCallback показывает комментарии задания вместо карточки по кнопке "comments:<id>"
+func (b *Botik) commentsCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, cb.Message.Chat.ID, id)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	text, err := b.commentsText(task)
+	if err != nil {
+		return "", fmt.Errorf("render comments: %w", err)
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBack, callbackData(TaskCallback, strconv.FormatInt(task.ID, 10))),
+	))
+	if _, err := b.bot.Send(tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID, text, markup)); err != nil {
+		return "", fmt.Errorf("edit task card: %w", err)
+	}
+
+	// Ответ на список комментариев тоже сохраняется как комментарий
+	b.linkTaskMessage(task.ID, cb.Message.Chat.ID, cb.Message.MessageID)
+	return "", nil
+}
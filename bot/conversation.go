@@ -0,0 +1,294 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+// Шаги диалога создания задания
+const (
+	stateWaitingTitle       = "waiting_for_title"
+	stateWaitingDescription = "waiting_for_description"
+	stateWaitingReward      = "waiting_for_reward"
+	stateWaitingDueDate     = "waiting_for_due_date"
+	stateConfirmDueDate     = "confirming_due_date"
+	stateWaitingAssignee    = "waiting_for_assignee"
+	stateWaitingAttachment  = "waiting_for_attachment" // Ожидание фото или документа к заданию
+	stateEditingField       = "editing_field"          // Ожидание нового значения поля задания
+)
+
+// Ключи, под которыми сохраняются введённые значения
+const (
+	keyTitle       = "title"
+	keyDescription = "description"
+	keyReward      = "reward"
+	keyDueAt       = "due_at"
+	keyTaskID      = "task_id"
+	keyProof       = "proof"
+	keyField       = "field"
+	keyCardMsgID   = "card_message_id"
+	keyVersion     = "version" // Версия задания на момент выбора поля для изменения
+)
+
+// handleConversation продолжает диалог, начатый пользователем в этом чате
+func (b *Botik) handleConversation(msg *tgbotapi.Message) {
+	ctx := b.ctx
+
+	conv, err := b.convRepo.Get(ctx, msg.Chat.ID, msg.From.ID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrConversationNotFound) {
+			slog.Error("failed to get conversation", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		if err := b.sendText(msg.Chat.ID, lang.EmptyValue, WithReply(msg.MessageID)); err != nil {
+			slog.Error(err.Error())
+		}
+		return
+	}
+
+	switch conv.State {
+	case stateWaitingTitle:
+		conv.Data[keyTitle] = text
+		b.nextStep(ctx, conv, stateWaitingDescription, lang.AskTaskDesc, msg.MessageID, cancelKeyboard())
+	case stateWaitingDescription:
+		conv.Data[keyDescription] = text
+		question := fmt.Sprintf(lang.AskTaskReward, b.chatCurrency(conv.ChatID))
+		b.nextStep(ctx, conv, stateWaitingReward, question, msg.MessageID, cancelKeyboard())
+	case stateWaitingReward:
+		amount, err := parseAmount(text)
+		if err != nil || amount < 0 {
+			if err := b.sendText(msg.Chat.ID, lang.InvalidReward, WithReply(msg.MessageID)); err != nil {
+				slog.Error(err.Error())
+			}
+			return
+		}
+
+		conv.Data[keyReward] = strconv.FormatInt(amount, 10)
+		b.nextStep(ctx, conv, stateWaitingDueDate, lang.AskTaskDueDate, msg.MessageID, dueDateKeyboard())
+	case stateWaitingDueDate, stateConfirmDueDate:
+		// Пока срок не подтверждён, пользователь может написать его заново
+		now := time.Now().In(b.chatLocation(conv.ChatID))
+
+		due, err := parseDueDate(text, now)
+		if err != nil {
+			if err := b.sendText(msg.Chat.ID, lang.InvalidDueDate, WithReply(msg.MessageID)); err != nil {
+				slog.Error(err.Error())
+			}
+			return
+		}
+		if !due.Time.After(now) {
+			if err := b.sendText(msg.Chat.ID, lang.DueDateInPast, WithReply(msg.MessageID)); err != nil {
+				slog.Error(err.Error())
+			}
+			return
+		}
+
+		conv.Data[keyDueAt] = due.Time.Format(time.RFC3339)
+		if due.Ambiguous {
+			question := fmt.Sprintf(lang.ConfirmDueDate, lang.WeekdaysShort[due.Time.Weekday()], due.Time.Format(dueLayout))
+			b.nextStep(ctx, conv, stateConfirmDueDate, question, msg.MessageID, confirmDueKeyboard())
+			return
+		}
+		b.askAssignee(ctx, conv, msg.MessageID)
+	case stateEditingField:
+		b.finishEdit(ctx, conv, msg, text)
+	case stateWaitingAttachment:
+		if err := b.sendText(msg.Chat.ID, lang.AttachmentExpected, WithReply(msg.MessageID)); err != nil {
+			slog.Error(err.Error())
+		}
+	case stateWaitingAssignee:
+		// Исполнитель выбирается только кнопкой, чтобы он был привязан к пользователю Telegram
+		if err := b.sendText(msg.Chat.ID, lang.PickAssignee, WithReply(msg.MessageID)); err != nil {
+			slog.Error(err.Error())
+		}
+	default:
+		slog.Warn("unknown conversation state", slog.String("state", conv.State))
+	}
+}
+
+// nextStep сохраняет диалог с новым шагом и задаёт пользователю следующий вопрос
+func (b *Botik) nextStep(
+	ctx context.Context,
+	conv entity.Conversation,
+	state, question string,
+	msgID int,
+	keyboard tgbotapi.InlineKeyboardMarkup,
+) {
+	conv.State = state
+
+	if err := b.convRepo.Save(ctx, conv); err != nil {
+		slog.Error("failed to save conversation", slog.String("error", err.Error()))
+		b.sendFailedStub(conv.ChatID, msgID)
+		return
+	}
+
+	if err := b.sendText(conv.ChatID, question, WithReply(msgID), WithKeyboard(keyboard)); err != nil {
+		slog.Error(err.Error())
+	}
+}
+
+// askAssignee переводит диалог к выбору исполнителя из участников чата
+func (b *Botik) askAssignee(ctx context.Context, conv entity.Conversation, msgID int) {
+	members, err := b.chatMembers(ctx, conv.ChatID)
+	if err != nil {
+		slog.Error("failed to get chat members", slog.String("error", err.Error()))
+		b.sendFailedStub(conv.ChatID, msgID)
+		return
+	}
+	b.nextStep(ctx, conv, stateWaitingAssignee, lang.AskTaskAssignee, msgID, assigneeKeyboard(members))
+}
+
+// dueDateCallback обрабатывает кнопки шага ввода срока: "due:none" создаёт задание без срока,
+// "due:ok" подтверждает срок, который бот понял не дословно
+func (b *Botik) dueDateCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", ErrMalformedCallback
+	}
+
+	conv, err := b.convRepo.Get(b.ctx, cb.Message.Chat.ID, cb.From.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			return lang.NotYourDialog, nil
+		}
+		return "", fmt.Errorf("get conversation: %w", err)
+	}
+
+	switch args[0] {
+	case dueNone:
+		if conv.State != stateWaitingDueDate && conv.State != stateConfirmDueDate {
+			return lang.NotYourDialog, nil
+		}
+		delete(conv.Data, keyDueAt)
+	case dueConfirm:
+		if conv.State != stateConfirmDueDate {
+			return lang.NotYourDialog, nil
+		}
+	default:
+		return "", ErrMalformedCallback
+	}
+
+	b.askAssignee(b.ctx, conv, cb.Message.MessageID)
+	return "", nil
+}
+
+// pickAssigneeCallback завершает создание задания после выбора исполнителя кнопкой "assignee:<userID>"
+func (b *Botik) pickAssigneeCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	assigneeID, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := b.ctx
+
+	conv, err := b.convRepo.Get(ctx, cb.Message.Chat.ID, cb.From.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			return lang.NotYourDialog, nil
+		}
+		return "", fmt.Errorf("get conversation: %w", err)
+	}
+	if conv.State != stateWaitingAssignee {
+		return lang.NotYourDialog, nil
+	}
+
+	isMember, err := b.chatRepo.IsMember(ctx, conv.ChatID, assigneeID)
+	if err != nil {
+		return "", fmt.Errorf("check chat member: %w", err)
+	}
+	if !isMember {
+		return lang.NotChatMember, nil
+	}
+
+	var dueAt time.Time
+	if raw, ok := conv.Data[keyDueAt]; ok {
+		if dueAt, err = time.Parse(time.RFC3339, raw); err != nil {
+			return "", fmt.Errorf("parse due date: %w", err)
+		}
+	}
+
+	// Диалоги, начатые до появления числовых наград, хранят награду текстом
+	reward := conv.Data[keyReward]
+	rewardAmount, err := strconv.ParseInt(reward, 10, 64)
+	if err == nil {
+		reward = ""
+	}
+
+	task := &entity.Task{
+		ChatID:       conv.ChatID,
+		Title:        conv.Data[keyTitle],
+		Description:  conv.Data[keyDescription],
+		Reward:       reward,
+		RewardAmount: rewardAmount,
+		AssigneeID:   assigneeID,
+		Status:       entity.StatusOpen,
+		DueAt:        dueAt,
+		CreatedBy:    conv.UserID,
+	}
+
+	if err := b.taskRepo.Create(ctx, task); err != nil {
+		return "", fmt.Errorf("create task: %w", err)
+	}
+
+	if err := b.convRepo.Delete(ctx, conv.ChatID, conv.UserID); err != nil {
+		slog.Error("failed to delete conversation", slog.String("error", err.Error()))
+	}
+
+	// Убираем клавиатуру выбора, чтобы исполнителя нельзя было выбрать повторно
+	edit := tgbotapi.NewEditMessageReplyMarkup(
+		cb.Message.Chat.ID,
+		cb.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}},
+	)
+	if _, err := b.bot.Send(edit); err != nil {
+		slog.Error("failed to edit message", slog.String("error", err.Error()))
+	}
+
+	return "", b.sendTaskCard(task, cb.From.ID, fmt.Sprintf(lang.TaskCreated, task.ID), cb.Message.MessageID)
+}
+
+// cancelConversation прерывает диалог пользователя, нажавшего кнопку отмены
+func (b *Botik) cancelConversation(cb *tgbotapi.CallbackQuery, _ []string) (string, error) {
+	ctx := b.ctx
+
+	conv, err := b.convRepo.Get(ctx, cb.Message.Chat.ID, cb.From.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			return lang.NothingToCancel, nil
+		}
+		return "", fmt.Errorf("get conversation: %w", err)
+	}
+
+	if err := b.convRepo.Delete(ctx, cb.Message.Chat.ID, cb.From.ID); err != nil {
+		return "", fmt.Errorf("delete conversation: %w", err)
+	}
+
+	edit := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, lang.Cancelled)
+	if _, err := b.bot.Send(edit); err != nil {
+		slog.Error("failed to edit message", slog.String("error", err.Error()))
+	}
+
+	// После отмены изменения задания меню полей снова становится карточкой
+	if conv.State == stateEditingField {
+		taskID, _ := strconv.ParseInt(conv.Data[keyTaskID], 10, 64)
+		cardMsgID, _ := strconv.Atoi(conv.Data[keyCardMsgID])
+		if err := b.reloadTaskCard(conv.ChatID, taskID, conv.UserID, cardMsgID); err != nil {
+			slog.Error("failed to refresh task card", slog.String("error", err.Error()))
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,406 @@
+package bot
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownDate срок не удалось разобрать
+var ErrUnknownDate = errors.New("unrecognized date expression")
+
+// Время, которое подставляется, если указан только день: срок истекает в конце дня
+const (
+	defaultDueHour   = 23
+	defaultDueMinute = 59
+)
+
+var (
+	numericDateRe = regexp.MustCompile(`^(\d{1,2})[./](\d{1,2})(?:[./](\d{2}|\d{4}))?$`)
+	clockRe       = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+)
+
+// Слова, которые не влияют на срок: "в пятницу", "к 18:00", "до 25.12"
+var datePrepositions = map[string]bool{
+	"в": true, "во": true, "к": true, "до": true, "на": true,
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"понедельник": time.Monday, "понедельника": time.Monday, "пн": time.Monday,
+	"вторник": time.Tuesday, "вторника": time.Tuesday, "вт": time.Tuesday,
+	"среда": time.Wednesday, "среду": time.Wednesday, "среды": time.Wednesday, "ср": time.Wednesday,
+	"четверг": time.Thursday, "четверга": time.Thursday, "чт": time.Thursday,
+	"пятница": time.Friday, "пятницу": time.Friday, "пятницы": time.Friday, "пт": time.Friday,
+	"суббота": time.Saturday, "субботу": time.Saturday, "субботы": time.Saturday, "сб": time.Saturday,
+	"воскресенье": time.Sunday, "воскресенья": time.Sunday, "вс": time.Sunday,
+}
+
+// Начала названий месяцев, чтобы подходили "декабря", "дек" и "декабрь"
+var monthPrefixes = []struct {
+	prefix string
+	month  time.Month
+}{
+	{"янв", time.January}, {"фев", time.February}, {"мар", time.March}, {"апр", time.April},
+	{"мая", time.May}, {"май", time.May}, {"июн", time.June}, {"июл", time.July},
+	{"авг", time.August}, {"сен", time.September}, {"окт", time.October}, {"ноя", time.November},
+	{"дек", time.December},
+}
+
+var numberWords = map[string]int{
+	"один": 1, "одна": 1, "одну": 1, "пару": 2, "два": 2, "две": 2, "три": 3, "четыре": 4,
+	"пять": 5, "шесть": 6, "семь": 7, "восемь": 8, "девять": 9, "десять": 10,
+}
+
+// dueDate Результат разбора срока
+type dueDate struct {
+	Time time.Time
+	// Часть срока достроена по догадке, например не указано время или не ясно, утро или вечер.
+	// Такой срок стоит показать пользователю для подтверждения
+	Ambiguous bool
+}
+
+// parseDueDate разбирает срок, записанный по-русски: "завтра в 18:00", "в пятницу", "через 3 дня",
+// "25.12", "25 декабря в 10 утра". Относительные выражения отсчитываются от now, результат
+// возвращается в часовом поясе now
+func parseDueDate(input string, now time.Time) (dueDate, error) {
+	p := &dateParser{now: now, tokens: dateTokens(input)}
+	if err := p.parse(); err != nil {
+		return dueDate{}, err
+	}
+	return p.result()
+}
+
+func dateTokens(input string) []string {
+	s := strings.ToLower(strings.TrimSpace(input))
+	s = strings.ReplaceAll(s, "ё", "е")
+	s = strings.ReplaceAll(s, ",", " ")
+	return strings.Fields(strings.TrimSuffix(s, "."))
+}
+
+type dateParser struct {
+	now    time.Time
+	tokens []string
+	pos    int
+
+	date         time.Time // Полночь выбранного дня, нулевое если день не указан
+	hour, minute int
+	hasTime      bool
+	offset       time.Duration // Точный сдвиг "через N минут/часов"
+	nextWeek     bool          // Встретилось "следующий", ждём день недели
+	ambiguous    bool
+}
+
+func (p *dateParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *dateParser) take() string {
+	tok := p.peek()
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *dateParser) today() time.Time {
+	return time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+}
+
+func (p *dateParser) parse() error {
+	if len(p.tokens) == 0 {
+		return ErrUnknownDate
+	}
+
+	prev := ""
+	for p.pos < len(p.tokens) {
+		tok := p.take()
+		weekday, isWeekday := weekdayNames[tok]
+
+		var err error
+		switch {
+		case datePrepositions[tok]:
+		case tok == "сегодня":
+			err = p.setDate(p.today())
+		case tok == "завтра":
+			err = p.setDate(p.today().AddDate(0, 0, 1))
+		case tok == "послезавтра":
+			err = p.setDate(p.today().AddDate(0, 0, 2))
+		case tok == "через":
+			err = p.parseRelative()
+		case tok == "полдень":
+			err = p.setTime(12, 0)
+		case tok == "утром":
+			p.ambiguous = true
+			err = p.setTime(9, 0)
+		case tok == "днем":
+			p.ambiguous = true
+			err = p.setTime(13, 0)
+		case tok == "вечером":
+			p.ambiguous = true
+			err = p.setTime(18, 0)
+		case strings.HasPrefix(tok, "следующ"):
+			p.nextWeek = true
+		case isWeekday:
+			err = p.setWeekday(weekday)
+		case numericDateRe.MatchString(tok):
+			err = p.parseNumericDate(numericDateRe.FindStringSubmatch(tok))
+		case clockRe.MatchString(tok):
+			m := clockRe.FindStringSubmatch(tok)
+			hour, _ := strconv.Atoi(m[1])
+			minute, _ := strconv.Atoi(m[2])
+			err = p.parseHour(hour, minute, true)
+		default:
+			n, convErr := strconv.Atoi(tok)
+			if convErr != nil {
+				return ErrUnknownDate
+			}
+			err = p.parseNumber(n, prev)
+		}
+		if err != nil {
+			return err
+		}
+		prev = tok
+	}
+
+	if p.nextWeek {
+		// "следующий" без дня недели
+		return ErrUnknownDate
+	}
+	return nil
+}
+
+func (p *dateParser) setDate(date time.Time) error {
+	if !p.date.IsZero() || p.offset != 0 {
+		return ErrUnknownDate
+	}
+	p.date = date
+	return nil
+}
+
+func (p *dateParser) setTime(hour, minute int) error {
+	if p.hasTime || p.offset != 0 || hour > 23 || minute > 59 {
+		return ErrUnknownDate
+	}
+	p.hour, p.minute, p.hasTime = hour, minute, true
+	return nil
+}
+
+// parseRelative разбирает продолжение "через": "через 3 дня", "через час", "через полчаса"
+func (p *dateParser) parseRelative() error {
+	tok := p.take()
+	if tok == "полчаса" {
+		p.offset += 30 * time.Minute
+		return nil
+	}
+
+	n := 1
+	if v, err := strconv.Atoi(tok); err == nil {
+		n, tok = v, p.take()
+	} else if v, ok := numberWords[tok]; ok {
+		n, tok = v, p.take()
+	}
+	if n <= 0 {
+		return ErrUnknownDate
+	}
+
+	switch {
+	case strings.HasPrefix(tok, "мин"):
+		p.offset += time.Duration(n) * time.Minute
+	case tok == "ч" || strings.HasPrefix(tok, "час"):
+		p.offset += time.Duration(n) * time.Hour
+	case strings.HasPrefix(tok, "дн"), strings.HasPrefix(tok, "ден"), strings.HasPrefix(tok, "сут"):
+		return p.setDate(p.today().AddDate(0, 0, n))
+	case strings.HasPrefix(tok, "недел"):
+		return p.setDate(p.today().AddDate(0, 0, 7*n))
+	case strings.HasPrefix(tok, "месяц"):
+		return p.setDate(addMonths(p.today(), n))
+	default:
+		return ErrUnknownDate
+	}
+
+	if !p.date.IsZero() || p.hasTime {
+		return ErrUnknownDate
+	}
+	return nil
+}
+
+// addMonths сдвигает дату на n месяцев. Если в том месяце нет такого числа, берётся его последний день
+func addMonths(date time.Time, n int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(n), 1, 0, 0, 0, 0, date.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(date.Day(), last)-1)
+}
+
+// setWeekday выбирает ближайший такой день недели после сегодняшнего или день следующей недели
+func (p *dateParser) setWeekday(wd time.Weekday) error {
+	today := p.today()
+
+	var days int
+	if p.nextWeek {
+		// Недели начинаются с понедельника
+		sinceMonday := (int(today.Weekday()) + 6) % 7
+		days = 7 - sinceMonday + (int(wd)+6)%7
+		p.nextWeek = false
+		p.ambiguous = true
+	} else {
+		days = (int(wd) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			// "в пятницу", сказанное в пятницу, скорее всего означает следующую
+			days = 7
+			p.ambiguous = true
+		}
+	}
+
+	return p.setDate(today.AddDate(0, 0, days))
+}
+
+func (p *dateParser) parseNumericDate(m []string) error {
+	day, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+
+	year := 0
+	if m[3] != "" {
+		year, _ = strconv.Atoi(m[3])
+		if year < 100 {
+			year += 2000
+		}
+	}
+	return p.setDayMonth(day, time.Month(month), year)
+}
+
+// setDayMonth устанавливает день. Если год не указан, берётся ближайший, в котором дата ещё не прошла
+func (p *dateParser) setDayMonth(day int, month time.Month, year int) error {
+	inferYear := year == 0
+	if inferYear {
+		year = p.now.Year()
+	}
+
+	date := time.Date(year, month, day, 0, 0, 0, 0, p.now.Location())
+	if inferYear && date.Before(p.today()) {
+		date = date.AddDate(1, 0, 0)
+		p.ambiguous = true
+	}
+
+	// time.Date нормализует 31.02 в март, такие даты отклоняем
+	if date.Day() != day || date.Month() != month {
+		return ErrUnknownDate
+	}
+	return p.setDate(date)
+}
+
+// parseNumber разбирает число по следующему слову: "25 декабря", "25 числа", "в 18", "6 вечера"
+func (p *dateParser) parseNumber(n int, prev string) error {
+	next := p.peek()
+
+	if month, ok := monthByName(next); ok {
+		p.pos++
+		return p.setDayMonth(n, month, 0)
+	}
+
+	if next == "числа" {
+		p.pos++
+		if n < 1 || n > 31 {
+			return ErrUnknownDate
+		}
+
+		// Ближайший месяц, в котором такое число есть и ещё не прошло: "31 числа" в апреле означает 31 мая
+		today := p.today()
+		for months := 0; ; months++ {
+			date := time.Date(today.Year(), today.Month()+time.Month(months), n, 0, 0, 0, 0, today.Location())
+			if date.Day() == n && !date.Before(today) {
+				return p.setDate(date)
+			}
+		}
+	}
+
+	if datePrepositions[prev] || strings.HasPrefix(next, "час") || isDayPeriod(next) {
+		return p.parseHour(n, 0, false)
+	}
+	return ErrUnknownDate
+}
+
+// parseHour устанавливает время с учётом уточнений "часов", "утра", "вечера".
+// Час без уточнения, записанный не в 24-часовом формате, считается неоднозначным
+func (p *dateParser) parseHour(hour, minute int, clock bool) error {
+	if strings.HasPrefix(p.peek(), "час") {
+		p.pos++
+	}
+
+	switch period := p.peek(); {
+	case isDayPeriod(period):
+		p.pos++
+		if hour > 12 {
+			return ErrUnknownDate
+		}
+		switch period {
+		case "утра":
+			if hour == 12 {
+				hour = 0
+			}
+		case "дня", "вечера":
+			if hour < 12 {
+				hour += 12
+			}
+		case "ночи":
+			if hour == 12 {
+				hour = 0
+			}
+		}
+	case !clock && hour >= 1 && hour <= 11:
+		p.ambiguous = true
+		// Сроки ранним утром редки, "в 6" скорее означает вечер
+		if hour <= 7 {
+			hour += 12
+		}
+	}
+
+	return p.setTime(hour, minute)
+}
+
+func isDayPeriod(tok string) bool {
+	return tok == "утра" || tok == "дня" || tok == "вечера" || tok == "ночи"
+}
+
+func monthByName(tok string) (time.Month, bool) {
+	for _, m := range monthPrefixes {
+		if strings.HasPrefix(tok, m.prefix) {
+			return m.month, true
+		}
+	}
+	return 0, false
+}
+
+func (p *dateParser) result() (dueDate, error) {
+	if p.offset != 0 {
+		return dueDate{Time: p.now.Add(p.offset).Truncate(time.Minute)}, nil
+	}
+	if p.date.IsZero() && !p.hasTime {
+		return dueDate{}, ErrUnknownDate
+	}
+
+	hour, minute := p.hour, p.minute
+	if !p.hasTime {
+		hour, minute = defaultDueHour, defaultDueMinute
+		p.ambiguous = true
+	}
+
+	date := p.date
+	if date.IsZero() {
+		// Указано только время: сегодня, а если оно уже прошло, то завтра
+		date = p.today()
+		if !time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location()).After(p.now) {
+			date = date.AddDate(0, 0, 1)
+			p.ambiguous = true
+		}
+	}
+
+	return dueDate{
+		Time:      time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location()),
+		Ambiguous: p.ambiguous,
+	}, nil
+}
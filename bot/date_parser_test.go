@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var msk = time.FixedZone("MSK", 3*60*60)
+
+func TestParseDueDate(t *testing.T) {
+	// Среда, 4 марта 2026, 14:30
+	wednesday := time.Date(2026, time.March, 4, 14, 30, 0, 0, msk)
+
+	tests := []struct {
+		input     string
+		now       time.Time // По умолчанию wednesday
+		want      string    // В формате dueLayout
+		ambiguous bool
+	}{
+		// Дни относительно сегодня
+		{input: "сегодня", want: "04.03.2026 23:59", ambiguous: true},
+		{input: "Завтра в 18:00", want: "05.03.2026 18:00"},
+		{input: "послезавтра к 9:30", want: "06.03.2026 09:30"},
+		{input: "завтра утром", want: "05.03.2026 09:00", ambiguous: true},
+		{input: "завтра днём", want: "05.03.2026 13:00", ambiguous: true},
+		{input: "завтра вечером.", want: "05.03.2026 18:00", ambiguous: true},
+
+		// Дни недели
+		{input: "в пятницу", want: "06.03.2026 23:59", ambiguous: true},
+		{input: "в пятницу в 10:00", want: "06.03.2026 10:00"},
+		{input: "пн 9:00", want: "09.03.2026 09:00"},
+		{input: "в воскресенье в 12:00", want: "08.03.2026 12:00"},
+		{input: "в среду в 10:00", want: "11.03.2026 10:00", ambiguous: true},
+		{input: "в следующий понедельник в 10:00", want: "09.03.2026 10:00", ambiguous: true},
+		{input: "в следующую среду в 10:00", want: "11.03.2026 10:00", ambiguous: true},
+		{input: "в следующее воскресенье в 10:00", want: "15.03.2026 10:00", ambiguous: true},
+		{input: "в среду в 10:00", now: time.Date(2026, time.December, 31, 12, 0, 0, 0, msk), want: "06.01.2027 10:00"},
+
+		// Сдвиги
+		{input: "через 3 дня", want: "07.03.2026 23:59", ambiguous: true},
+		{input: "через день в 12:00", want: "05.03.2026 12:00"},
+		{input: "через две недели", want: "18.03.2026 23:59", ambiguous: true},
+		{input: "через месяц в 10:00", want: "04.04.2026 10:00"},
+		{input: "через месяц в 10:00", now: time.Date(2026, time.January, 31, 9, 0, 0, 0, msk), want: "28.02.2026 10:00"},
+		{input: "через месяц в 10:00", now: time.Date(2028, time.January, 30, 9, 0, 0, 0, msk), want: "29.02.2028 10:00"},
+		{input: "через 2 месяца", now: time.Date(2026, time.December, 31, 9, 0, 0, 0, msk), want: "28.02.2027 23:59", ambiguous: true},
+		{input: "через 2 часа", want: "04.03.2026 16:30"},
+		{input: "через час", want: "04.03.2026 15:30"},
+		{input: "через полчаса", want: "04.03.2026 15:00"},
+		{input: "через пять минут", want: "04.03.2026 14:35"},
+		{input: "через 90 мин", now: time.Date(2026, time.March, 4, 23, 15, 40, 0, msk), want: "05.03.2026 00:45"},
+
+		// Числовые даты
+		{input: "25.12", want: "25.12.2026 23:59", ambiguous: true},
+		{input: "25/12 18:00", want: "25.12.2026 18:00"},
+		{input: "25.12.27 10:00", want: "25.12.2027 10:00"},
+		{input: "до 01.03.2027 10:00", want: "01.03.2027 10:00"},
+		{input: "01.03 10:00", want: "01.03.2027 10:00", ambiguous: true},
+		{input: "29.02.2028 12:00", want: "29.02.2028 12:00"},
+		{input: "25.12 10:00", now: time.Date(2026, time.December, 26, 9, 0, 0, 0, msk), want: "25.12.2027 10:00", ambiguous: true},
+
+		// Даты словами
+		{input: "25 декабря в 10 утра", want: "25.12.2026 10:00"},
+		{input: "1 мая в 12 дня", want: "01.05.2026 12:00"},
+		{input: "8 мар в 7 вечера", want: "08.03.2026 19:00"},
+		{input: "2 января", want: "02.01.2027 23:59", ambiguous: true},
+		{input: "15 числа в 18:00", want: "15.03.2026 18:00"},
+		{input: "3 числа в 18:00", want: "03.04.2026 18:00"},
+		{input: "31 числа в 18:00", now: time.Date(2026, time.April, 30, 9, 0, 0, 0, msk), want: "31.05.2026 18:00"},
+		{input: "29 числа в 18:00", now: time.Date(2027, time.February, 1, 9, 0, 0, 0, msk), want: "29.03.2027 18:00"},
+
+		// Только время
+		{input: "в 20:00", want: "04.03.2026 20:00"},
+		{input: "к 9:00", want: "05.03.2026 09:00", ambiguous: true},
+		{input: "в 6 вечера", want: "04.03.2026 18:00"},
+		{input: "в 6", want: "04.03.2026 18:00", ambiguous: true},
+		{input: "в 10", want: "05.03.2026 10:00", ambiguous: true},
+		{input: "в 16 часов", want: "04.03.2026 16:00"},
+		{input: "в 12 ночи", want: "05.03.2026 00:00", ambiguous: true},
+		{input: "в 3 ночи", want: "05.03.2026 03:00", ambiguous: true},
+		{input: "в полдень", want: "05.03.2026 12:00", ambiguous: true},
+	}
+	for _, tt := range tests {
+		now := wednesday
+		if !tt.now.IsZero() {
+			now = tt.now
+		}
+
+		t.Run(tt.input+" "+now.Format("02.01"), func(t *testing.T) {
+			got, err := parseDueDate(tt.input, now)
+			if err != nil {
+				t.Fatalf("parseDueDate(%q) error: %v", tt.input, err)
+			}
+			if got.Time.Location() != msk {
+				t.Errorf("parseDueDate(%q) location %s, want %s", tt.input, got.Time.Location(), msk)
+			}
+			if s := got.Time.Format(dueLayout); s != tt.want || got.Ambiguous != tt.ambiguous {
+				t.Errorf("parseDueDate(%q) = %s ambiguous=%v, want %s ambiguous=%v", tt.input, s, got.Ambiguous, tt.want, tt.ambiguous)
+			}
+		})
+	}
+}
+
+func TestParseDueDateErrors(t *testing.T) {
+	now := time.Date(2026, time.March, 4, 14, 30, 0, 0, msk)
+
+	for _, input := range []string{
+		"",
+		"   ",
+		"когда-нибудь",
+		"следующий",
+		"в следующий",
+		"завтра послезавтра",
+		"завтра в 10:00 в 11:00",
+		"через 2 часа завтра",
+		"завтра через час",
+		"через",
+		"через 0 дней",
+		"через 3 года",
+		"25:00",
+		"10:60",
+		"в 13 вечера",
+		"31.04",
+		"29.02",
+		"29.02.2027",
+		"0.12",
+		"12.13",
+		"32 числа",
+		"0 числа",
+		"30 февраля",
+		"25",
+	} {
+		t.Run(input, func(t *testing.T) {
+			got, err := parseDueDate(input, now)
+			if !errors.Is(err, ErrUnknownDate) {
+				t.Errorf("parseDueDate(%q) = %s, %v, want ErrUnknownDate", input, got.Time.Format(dueLayout), err)
+			}
+		})
+	}
+}
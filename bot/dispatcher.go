@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// dispatcher распределяет обновления между воркерами. Обновления одного чата всегда
+// попадают к одному воркеру, поэтому обрабатываются в порядке поступления,
+// а разные чаты обрабатываются параллельно
+type dispatcher struct {
+	queues []chan tgbotapi.Update
+	handle func(tgbotapi.Update)
+	wg     sync.WaitGroup
+
+	// Сколько раз обновление ждало освобождения места в очереди воркера
+	stalls atomic.Int64
+}
+
+func newDispatcher(workers, queueSize int, handle func(tgbotapi.Update)) *dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+
+	d := &dispatcher{
+		queues: make([]chan tgbotapi.Update, workers),
+		handle: handle,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+	}
+
+	return d
+}
+
+func (d *dispatcher) start() {
+	for i := range d.queues {
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+}
+
+func (d *dispatcher) work(queue <-chan tgbotapi.Update) {
+	defer d.wg.Done()
+
+	for update := range queue {
+		d.handle(update)
+	}
+}
+
+// dispatch ставит обновление в очередь воркера его чата. Если очередь заполнена,
+// сообщает об этом и ждёт, не давая получать новые обновления быстрее, чем они обрабатываются
+func (d *dispatcher) dispatch(update tgbotapi.Update) {
+	chatID := updateChatID(update)
+	worker := int(uint64(chatID) % uint64(len(d.queues)))
+	queue := d.queues[worker]
+
+	select {
+	case queue <- update:
+		return
+	default:
+	}
+
+	slog.Warn(
+		"update queue is full, waiting for worker",
+		slog.Int("worker", worker),
+		slog.Int64("chat_id", chatID),
+		slog.Int64("stalls", d.stalls.Add(1)),
+	)
+	queue <- update
+}
+
+// close закрывает очереди и дожидается обработки всех поставленных в них обновлений
+func (d *dispatcher) close() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+// updateChatID возвращает ID чата, к которому относится обновление, или 0, если чата нет
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.MyChatMember != nil:
+		return update.MyChatMember.Chat.ID
+	case update.ChatMember != nil:
+		return update.ChatMember.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message == nil:
+		return 0
+	}
+
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chatUpdate сообщение из чата chatID с номером id
+func chatUpdate(id int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{UpdateID: id, Message: &tgbotapi.Message{MessageID: id, Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func TestDispatcherKeepsChatOrderAndRunsChatsInParallel(t *testing.T) {
+	// Чаты 2 и 3 попадают к разным воркерам
+	const slowChat, fastChat = 2, 3
+
+	var (
+		mu      sync.Mutex
+		handled = map[int64][]int{}
+	)
+	fastDone := make(chan struct{})
+	var fastOnce sync.Once
+
+	d := newDispatcher(2, 100, func(update tgbotapi.Update) {
+		chatID := update.Message.Chat.ID
+
+		// Первое обновление медленного чата ждёт, пока обработается быстрый чат.
+		// Если бы чаты обрабатывались последовательно, ожидание никогда бы не закончилось
+		if chatID == slowChat && update.UpdateID == 1 {
+			select {
+			case <-fastDone:
+			case <-time.After(5 * time.Second):
+				t.Error("other chat was not handled while this chat was busy")
+			}
+		}
+
+		mu.Lock()
+		handled[chatID] = append(handled[chatID], update.UpdateID)
+		mu.Unlock()
+
+		if chatID == fastChat {
+			fastOnce.Do(func() { close(fastDone) })
+		}
+	})
+	d.start()
+
+	var want []int
+	for i := 1; i <= 20; i++ {
+		d.dispatch(chatUpdate(i, slowChat))
+		want = append(want, i)
+	}
+	d.dispatch(chatUpdate(100, fastChat))
+	d.close()
+
+	if got := handled[slowChat]; !slices.Equal(got, want) {
+		t.Errorf("chat updates handled in order %v, want %v", got, want)
+	}
+	if got := handled[fastChat]; !slices.Equal(got, []int{100}) {
+		t.Errorf("other chat updates %v", got)
+	}
+}
+
+func TestDispatcherBlocksWhenQueueIsFull(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	var handled []int
+
+	d := newDispatcher(1, 1, func(update tgbotapi.Update) {
+		started <- struct{}{}
+		<-release
+		handled = append(handled, update.UpdateID)
+	})
+	d.start()
+
+	// Первое обновление занимает воркер, второе очередь
+	d.dispatch(chatUpdate(1, 1))
+	<-started
+	d.dispatch(chatUpdate(2, 1))
+	if stalls := d.stalls.Load(); stalls != 0 {
+		t.Fatalf("stalls %d before the queue is full", stalls)
+	}
+
+	dispatched := make(chan struct{})
+	go func() {
+		d.dispatch(chatUpdate(3, 1))
+		close(dispatched)
+	}()
+
+	select {
+	case <-dispatched:
+		t.Fatal("dispatch returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch did not resume after the worker freed the queue")
+	}
+	d.close()
+
+	if stalls := d.stalls.Load(); stalls != 1 {
+		t.Errorf("stalls %d, want 1", stalls)
+	}
+	if !strings.Contains(logs.String(), "update queue is full") || !strings.Contains(logs.String(), "stalls=1") {
+		t.Errorf("no stall warning in logs: %s", logs.String())
+	}
+	if !slices.Equal(handled, []int{1, 2, 3}) {
+		t.Errorf("handled %v, want all updates in order", handled)
+	}
+}
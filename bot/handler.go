@@ -1,39 +1,81 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
 	"github.com/qrave1/task-track/lang"
 )
 
+// handleUpdates передаёт обновления воркерам, пока бот не будет остановлен
 func (b *Botik) handleUpdates() {
-	for update := range b.updates {
-		switch {
-		case update.Message != nil:
-
-			switch {
-			case update.Message.IsCommand():
-				slog.Info(
-					"got new command",
-					slog.String("command", update.Message.Command()),
-				)
-
-				b.handleCommand(update.Message)
-			default:
-				slog.Info(
-					"got new message",
-					slog.String(update.Message.Text, update.Message.Text),
-				)
-
-				b.handleMessage(update.Message)
+	defer close(b.done)
+
+	b.dispatcher.start()
+	defer b.dispatcher.close()
+
+	for {
+		select {
+		case update, ok := <-b.updates:
+			if !ok {
+				return
 			}
-		case update.CallbackQuery != nil:
-			slog.Info("got new callback query")
+			b.dispatcher.dispatch(update)
+		case <-b.stop:
+			b.drainUpdates()
+			return
+		}
+	}
+}
+
+// drainUpdates дорабатывает обновления, уже полученные от Telegram к моменту остановки
+func (b *Botik) drainUpdates() {
+	for {
+		select {
+		case update, ok := <-b.updates:
+			if !ok {
+				return
+			}
+			b.dispatcher.dispatch(update)
+		default:
+			return
+		}
+	}
+}
+
+func (b *Botik) handleUpdate(update tgbotapi.Update) {
+	b.trackUser(b.ctx, update.SentFrom())
+
+	switch {
+	case update.Message != nil:
 
-			b.handleCallbackQuery(update.CallbackQuery)
+		switch {
+		case update.Message.IsCommand():
+			slog.Info(
+				"got new command",
+				slog.String("command", update.Message.Command()),
+			)
+
+			b.handleCommand(update.Message)
+		default:
+			slog.Info(
+				"got new message",
+				slog.String(update.Message.Text, update.Message.Text),
+			)
+
+			b.handleMessage(update.Message)
 		}
+	case update.CallbackQuery != nil:
+		slog.Info("got new callback query")
+
+		b.handleCallbackQuery(update.CallbackQuery)
+	case update.ChatMember != nil:
+		b.handleChatMemberUpdate(update.ChatMember)
+	case update.MyChatMember != nil:
+		b.handleMyChatMemberUpdate(update.MyChatMember)
 	}
 }
 
@@ -41,23 +83,138 @@ func (b *Botik) handleMessage(msg *tgbotapi.Message) {
 	// События, при добавлении новых участников
 	if msg.NewChatMembers != nil {
 		b.handleNewChatMember(msg)
+		return
+	}
+
+	if msg.LeftChatMember != nil {
+		b.handleLeftChatMember(msg)
+		return
+	}
+
+	// Фото и документы прикладываются к заданию, которое ждёт вложение или на карточку которого ответили
+	if msg.From != nil && b.handleAttachmentMessage(msg) {
+		return
+	}
+
+	// Ответ на карточку задания сохраняется как комментарий
+	if msg.From != nil && msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil &&
+		msg.ReplyToMessage.From.ID == b.bot.Self.ID && b.handleTaskReply(msg) {
+		return
+	}
+
+	if msg.From != nil && msg.Text != "" {
+		b.handleConversation(msg)
 	}
 }
 
 func (b *Botik) handleCallbackQuery(cb *tgbotapi.CallbackQuery) {
+	answer, err := b.callbacks.route(cb)
+	switch {
+	case errors.Is(err, ErrMalformedCallback):
+		slog.Warn("malformed callback query", slog.String("data", cb.Data))
+		answer = lang.MalformedCallback
+	case errors.Is(err, ErrUnknownCallback):
+		slog.Warn("unknown callback action", slog.String("data", cb.Data))
+		answer = lang.UnknownAction
+	case errors.Is(err, ErrForbidden):
+		answer = lang.Forbidden
+	case err != nil:
+		slog.Error("handle callback query", slog.String("data", cb.Data), slog.String("error", err.Error()))
+		answer = lang.FailedStub
+	}
+
+	// Telegram показывает индикатор загрузки на кнопке, пока запрос не получит ответ
+	if _, err := b.bot.Request(tgbotapi.NewCallback(cb.ID, answer)); err != nil {
+		slog.Error("failed to answer callback query", slog.String("error", err.Error()))
+	}
+}
 
+func (b *Botik) registerCallbacks() {
+	b.callbacks.Handle(CancelCallback, "", b.cancelConversation)
+	b.callbacks.Handle(MenuCallback, entity.ActionView, b.menuCallback)
+	b.callbacks.Handle(DueCallback, entity.ActionCreate, b.dueDateCallback)
+	b.callbacks.Handle(AssigneeCallback, entity.ActionCreate, b.pickAssigneeCallback)
+	b.callbacks.Handle(TaskCallback, entity.ActionView, b.showTaskCallback)
+	b.callbacks.Handle(StatusCallback, entity.ActionView, b.changeStatusCallback)
+	b.callbacks.Handle(SeriesCallback, entity.ActionCreate, b.seriesCallback)
+	b.callbacks.Handle(ShopCallback, entity.ActionRedeem, b.shopCallback)
+	b.callbacks.Handle(RedeemCallback, entity.ActionManage, b.redeemCallback)
+	b.callbacks.Handle(ChecklistCallback, entity.ActionView, b.checklistCallback)
+	b.callbacks.Handle(ListCallback, entity.ActionView, b.listCallback)
+	b.callbacks.Handle(EditCallback, entity.ActionView, b.editCallback)
+	b.callbacks.Handle(DeleteCallback, entity.ActionView, b.deleteCallback)
+	b.callbacks.Handle(CommentsCallback, entity.ActionView, b.commentsCallback)
+	b.callbacks.Handle(AttachCallback, entity.ActionView, b.attachCallback)
+	b.callbacks.Handle(FilesCallback, entity.ActionView, b.filesCallback)
 }
 
 func (b *Botik) handleCommand(msg *tgbotapi.Message) {
+	if perm, ok := commandPermissions[msg.Command()]; ok {
+		if msg.From == nil {
+			return
+		}
+
+		err := b.authorize(msg.Chat.ID, msg.From.ID, perm)
+		if errors.Is(err, ErrForbidden) {
+			if err := b.sendText(msg.Chat.ID, lang.Forbidden, WithReply(msg.MessageID)); err != nil {
+				slog.Error(err.Error())
+			}
+			return
+		}
+		if err != nil {
+			slog.Error("authorize command", slog.String("command", msg.Command()), slog.String("error", err.Error()))
+			b.sendFailedStub(msg.Chat.ID, msg.MessageID)
+			return
+		}
+	}
+
 	switch msg.Command() {
 	case StartCommand:
 		b.StartCmd(msg.Chat.ID, msg.MessageID)
 	case HelpCommand:
 		b.HelpCmd(msg.Chat.ID, msg.MessageID)
 	case NewCommand:
-		return
+		b.NewCmd(msg.Chat.ID, msg.From.ID, msg.MessageID)
+	case TaskCommand:
+		b.TaskCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
 	case InitChatCommand:
 		b.initChatCmd(msg.Chat.ID, msg.MessageID)
+	case RoleCommand:
+		b.RoleCmd(msg.Chat.ID, msg.MessageID, msg.CommandArguments())
+	case TimezoneCommand:
+		b.TimezoneCmd(msg.Chat.ID, msg.MessageID, msg.CommandArguments())
+	case RepeatCommand:
+		b.RepeatCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case SeriesCommand:
+		b.SeriesCmd(msg.Chat.ID, msg.MessageID)
+	case BalanceCommand:
+		b.BalanceCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case TopCommand:
+		b.TopCmd(msg.Chat.ID, msg.MessageID)
+	case AdjustCommand:
+		b.AdjustCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case CurrencyCommand:
+		b.CurrencyCmd(msg.Chat.ID, msg.MessageID, msg.CommandArguments())
+	case ShopCommand:
+		b.ShopCmd(msg.Chat.ID, msg.MessageID)
+	case PrizeAddCommand:
+		b.PrizeAddCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case PrizeRmCommand:
+		b.PrizeRemoveCmd(msg.Chat.ID, msg.MessageID, msg.CommandArguments())
+	case TasksCommand:
+		b.TasksCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case OrdersCommand:
+		b.OrdersCmd(msg.Chat.ID, msg.MessageID)
+	case CheckCommand:
+		b.CheckCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case CheckRemoveCommand:
+		b.CheckRemoveCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case AutoDoneCommand:
+		b.AutoDoneCmd(msg.Chat.ID, msg.From.ID, msg.MessageID, msg.CommandArguments())
+	case LinkAssigneeCommand:
+		b.LinkAssigneeCmd(msg.Chat.ID, msg.MessageID, msg.CommandArguments())
+	case AdoptTasksCommand:
+		b.AdoptTasksCmd(msg.Chat.ID, msg.MessageID)
 	}
 }
 
@@ -67,10 +224,23 @@ func (b *Botik) handleNewChatMember(msg *tgbotapi.Message) {
 		if member.UserName == b.bot.Self.UserName {
 			slog.Info(fmt.Sprintf("added to %s (%s) with ID %d", msg.Chat.Title, msg.Chat.Type, msg.Chat.ID))
 
+			b.setChatActive(msg.Chat.ID, true)
+
 			// Отправляем приветственное сообщение
-			if err := b.sendText(msg.Chat.ID, lang.BotAddedToGroup); err != nil {
-				slog.Error(err.Error())
-			}
+			b.enqueue(tgbotapi.NewMessage(msg.Chat.ID, lang.BotAddedToGroup))
+			continue
 		}
+
+		b.memberJoined(msg.Chat.ID, &member, entity.RoleMember)
+	}
+}
+
+func (b *Botik) handleLeftChatMember(msg *tgbotapi.Message) {
+	if msg.LeftChatMember.ID == b.bot.Self.ID {
+		slog.Info(fmt.Sprintf("removed from %s (%s) with ID %d", msg.Chat.Title, msg.Chat.Type, msg.Chat.ID))
+		b.setChatActive(msg.Chat.ID, false)
+		return
 	}
+
+	b.memberLeft(msg.Chat.ID, msg.LeftChatMember)
 }
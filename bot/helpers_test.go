@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/qrave1/task-track/config"
+	"github.com/qrave1/task-track/migrations"
+	"github.com/qrave1/task-track/repository"
+)
+
+// newTestDB открывает базу в памяти со всеми миграциями
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	// У каждого соединения своя база в памяти
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.Apply(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// fakeClock часы, которые двигает тест. Тики доставляются планировщику синхронно
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	ticks chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, ticks: make(chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(time.Duration) Ticker {
+	return fakeTicker{c: c.ticks}
+}
+
+// Advance переводит часы на d и присылает тик. Возвращается, когда планировщик принял тик
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	c.mu.Unlock()
+
+	c.ticks <- now
+}
+
+type fakeTicker struct {
+	c chan time.Time
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (fakeTicker) Stop() {}
+
+// apiCall запрос, полученный поддельным Bot API
+type apiCall struct {
+	Method string
+	Params url.Values
+}
+
+// fakeAPI поддельный сервер Bot API. Запоминает запросы и отвечает успехом
+type fakeAPI struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	calls     []apiCall
+	webhook   string
+	messageID int
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+
+	api := &fakeAPI{}
+	api.server = httptest.NewServer(http.HandlerFunc(api.serve))
+	t.Cleanup(api.server.Close)
+	return api
+}
+
+// endpoint шаблон адреса для tgbotapi.NewBotAPIWithAPIEndpoint
+func (a *fakeAPI) endpoint() string {
+	return a.server.URL + "/bot%s/%s"
+}
+
+func (a *fakeAPI) serve(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	method := path.Base(r.URL.Path)
+	a.calls = append(a.calls, apiCall{Method: method, Params: r.Form})
+
+	var result any
+	switch method {
+	case "getMe":
+		result = map[string]any{"id": 1, "is_bot": true, "first_name": "Test", "username": "test_bot"}
+	case "setWebhook":
+		a.webhook = r.Form.Get("url")
+		result = true
+	case "getWebhookInfo":
+		result = map[string]any{"url": a.webhook}
+	case "getChatMember":
+		userID, _ := strconv.ParseInt(r.Form.Get("user_id"), 10, 64)
+		result = map[string]any{"user": map[string]any{"id": userID, "first_name": "User"}, "status": "member"}
+	case "getChatAdministrators":
+		result = []any{}
+	case "sendMessage", "editMessageText", "editMessageReplyMarkup", "sendPhoto", "sendDocument":
+		a.messageID++
+		chatID, _ := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+		result = map[string]any{"message_id": a.messageID, "date": 0, "chat": map[string]any{"id": chatID}}
+	default:
+		result = true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// callsTo возвращает запросы к методу Bot API в порядке поступления
+func (a *fakeAPI) callsTo(method string) []apiCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var calls []apiCall
+	for _, call := range a.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// newTestConfig настройки бота, подключённого к поддельному Bot API
+func newTestConfig(api *fakeAPI) *config.Config {
+	cfg := &config.Config{
+		ShutdownTimeout:   5 * time.Second,
+		Workers:           1,
+		QueueSize:         1,
+		TimeZone:          "UTC",
+		SchedulerInterval: time.Minute,
+	}
+	cfg.Reminders.Offsets = []time.Duration{time.Hour}
+	cfg.Telegram.Token = "test"
+	cfg.Telegram.APIEndpoint = api.endpoint()
+	return cfg
+}
+
+// newTestBotik создаёт бота с базой в памяти
+func newTestBotik(t *testing.T, cfg *config.Config, db *sql.DB) *Botik {
+	t.Helper()
+
+	b, err := NewBotik(
+		cfg,
+		repository.NewTaskRepositoryImpl(db),
+		repository.NewChatRepositoryImpl(db),
+		repository.NewConversationRepositoryImpl(db),
+		repository.NewUserRepositoryImpl(db),
+		repository.NewReminderRepositoryImpl(db),
+		repository.NewSeriesRepositoryImpl(db),
+		repository.NewLedgerRepositoryImpl(db),
+		repository.NewShopRepositoryImpl(db),
+		repository.NewChecklistRepositoryImpl(db),
+		repository.NewCommentRepositoryImpl(db),
+		repository.NewAttachmentRepositoryImpl(db),
+		repository.NewTaskListRepositoryImpl(db),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.cancel)
+	return b
+}
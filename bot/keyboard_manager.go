@@ -1 +1,93 @@
 package bot
+
+import (
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+)
+
+const (
+	CancelCallback   = "cancel"
+	MenuCallback     = "menu"
+	AssigneeCallback = "assignee"
+	DueCallback      = "due"
+)
+
+// Аргументы кнопок шага ввода срока
+const (
+	dueNone    = "none"
+	dueConfirm = "ok"
+)
+
+// cancelKeyboard клавиатура с единственной кнопкой отмены текущего диалога
+// menuNew Аргумент кнопки главного меню, начинающей создание задания
+const menuNew = "new"
+
+// mainMenuKeyboard клавиатура главного меню
+func mainMenuKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.ButtonTaskList, callbackData(ListCallback, "0")),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.ButtonNewTask, callbackData(MenuCallback, menuNew)),
+		),
+	)
+}
+
+// menuRow строка с кнопкой возврата в главное меню
+func menuRow() []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(lang.ButtonMenu, MenuCallback))
+}
+
+func cancelKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.Cancel, CancelCallback),
+		),
+	)
+}
+
+// dueDateKeyboard клавиатура шага ввода срока: задание можно создать без срока
+func dueDateKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.NoDueDate, callbackData(DueCallback, dueNone)),
+			tgbotapi.NewInlineKeyboardButtonData(lang.Cancel, CancelCallback),
+		),
+	)
+}
+
+// confirmDueKeyboard клавиатура подтверждения срока, понятого не дословно
+func confirmDueKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.ConfirmDue, callbackData(DueCallback, dueConfirm)),
+			tgbotapi.NewInlineKeyboardButtonData(lang.NoDueDate, callbackData(DueCallback, dueNone)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.Cancel, CancelCallback),
+		),
+	)
+}
+
+// assigneeKeyboard клавиатура выбора исполнителя из участников чата
+func assigneeKeyboard(members []entity.User) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(members)+1)
+	for _, member := range members {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				member.DisplayName(),
+				callbackData(AssigneeCallback, strconv.FormatInt(member.ID, 10)),
+			),
+		))
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(lang.Cancel, CancelCallback),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,277 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+// Сколько участников показывать в рейтинге и записей в истории баланса
+const (
+	leaderboardSize = 10
+	historySize     = 5
+)
+
+// parseAmount читает сумму из начала строки: "100", "-50 баллов"
+func parseAmount(text string) (int64, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.ParseInt(fields[0], 10, 64)
+}
+
+// chatCurrency возвращает валюту наград чата
+func (b *Botik) chatCurrency(chatID int64) string {
+	chat, err := b.chatRepo.GetByID(b.ctx, chatID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrChatNotFound) {
+			slog.Error("failed to get chat", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		}
+		return lang.DefaultCurrency
+	}
+
+	if chat.Currency == "" {
+		return lang.DefaultCurrency
+	}
+	return chat.Currency
+}
+
+// formatAmount сумма вместе с валютой чата
+func (b *Botik) formatAmount(chatID, amount int64) string {
+	return fmt.Sprintf(lang.Amount, amount, b.chatCurrency(chatID))
+}
+
+// rewardText награда задания для карточки
+func (b *Botik) rewardText(task *entity.Task) string {
+	switch {
+	case task.RewardAmount > 0:
+		return b.formatAmount(task.ChatID, task.RewardAmount)
+	case task.Reward != "":
+		return task.Reward
+	default:
+		return lang.NoReward
+	}
+}
+
+// announceReward сообщает в чат о начислении награды за принятое задание
+func (b *Botik) announceReward(task *entity.Task) {
+	if task.RewardAmount <= 0 || task.AssigneeID == 0 {
+		return
+	}
+
+	text := fmt.Sprintf(lang.RewardCredited, b.assigneeMention(b.ctx, task), b.formatAmount(task.ChatID, task.RewardAmount), task.ID)
+	if err := b.sendText(task.ChatID, text); err != nil {
+		slog.Error("failed to announce reward", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+	}
+}
+
+// ledgerEntryText строка истории баланса
+func (b *Botik) ledgerEntryText(entry entity.LedgerEntry) string {
+	amount := fmt.Sprintf(lang.SignedAmount, entry.Amount, b.chatCurrency(entry.ChatID))
+	switch entry.Kind {
+	case entity.LedgerTaskReward:
+		return fmt.Sprintf(lang.LedgerTaskReward, amount, entry.TaskID)
+	case entity.LedgerPurchase:
+		return fmt.Sprintf(lang.LedgerPurchase, amount, entry.Reason)
+	case entity.LedgerRefund:
+		return fmt.Sprintf(lang.LedgerRefund, amount, entry.Reason)
+	default:
+		return fmt.Sprintf(lang.LedgerAdjustment, amount, entry.Reason)
+	}
+}
+
+// BalanceCmd показывает баланс пользователя и последние операции: /balance или /balance @username
+func (b *Botik) BalanceCmd(chatID, userID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /balance command", slog.String("error", err.Error()))
+		}
+	}
+	fail := func(err error) {
+		slog.Error("handle /balance command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+	}
+
+	user := entity.User{ID: userID}
+	if username := strings.TrimSpace(args); username != "" {
+		var err error
+		user, err = b.userRepo.GetByUsername(b.ctx, username)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			reply(lang.UserNotFound)
+			return
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+	} else if known, err := b.userRepo.GetByID(b.ctx, userID); err == nil {
+		user = known
+	}
+
+	balance, err := b.ledgerRepo.Balance(b.ctx, chatID, user.ID)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	history, err := b.ledgerRepo.History(b.ctx, chatID, user.ID, historySize)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	lines := []string{fmt.Sprintf(lang.BalanceLine, user.Mention(), b.formatAmount(chatID, balance))}
+	for _, entry := range history {
+		lines = append(lines, b.ledgerEntryText(entry))
+	}
+	reply(strings.Join(lines, "\n"))
+}
+
+// TopCmd показывает участников чата с наибольшим балансом
+func (b *Botik) TopCmd(chatID int64, msgID int) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /top command", slog.String("error", err.Error()))
+		}
+	}
+
+	balances, err := b.ledgerRepo.Leaderboard(b.ctx, chatID, leaderboardSize)
+	if err != nil {
+		slog.Error("handle /top command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+	if len(balances) == 0 {
+		reply(lang.LeaderboardEmpty)
+		return
+	}
+
+	ids := make([]int64, 0, len(balances))
+	for _, balance := range balances {
+		ids = append(ids, balance.UserID)
+	}
+
+	users, err := b.userRepo.GetByIDs(b.ctx, ids)
+	if err != nil {
+		slog.Error("handle /top command", slog.String("error", err.Error()))
+		users = map[int64]entity.User{}
+	}
+
+	lines := []string{lang.LeaderboardHeader}
+	for i, balance := range balances {
+		user, ok := users[balance.UserID]
+		if !ok {
+			user = entity.User{ID: balance.UserID}
+		}
+		lines = append(lines, fmt.Sprintf(lang.LeaderboardLine, i+1, user.DisplayName(), b.formatAmount(chatID, balance.Amount)))
+	}
+	reply(strings.Join(lines, "\n"))
+}
+
+// AdjustCmd вручную меняет баланс участника: /adjust @username <сумма> <причина>
+func (b *Botik) AdjustCmd(chatID, userID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /adjust command", slog.String("error", err.Error()))
+		}
+	}
+	fail := func(err error) {
+		slog.Error("handle /adjust command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) < 3 {
+		reply(lang.AdjustUsage)
+		return
+	}
+
+	amount, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || amount == 0 {
+		reply(lang.AdjustUsage)
+		return
+	}
+	reason := strings.Join(fields[2:], " ")
+
+	user, err := b.userRepo.GetByUsername(b.ctx, fields[0])
+	if errors.Is(err, repository.ErrUserNotFound) {
+		reply(lang.UserNotFound)
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	isMember, err := b.chatRepo.IsMember(b.ctx, chatID, user.ID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if !isMember {
+		reply(lang.NotChatMember)
+		return
+	}
+
+	entry := &entity.LedgerEntry{
+		ChatID:    chatID,
+		UserID:    user.ID,
+		Amount:    amount,
+		Kind:      entity.LedgerAdjustment,
+		Reason:    reason,
+		CreatedBy: userID,
+	}
+	if err := b.ledgerRepo.Append(b.ctx, entry); err != nil {
+		fail(err)
+		return
+	}
+
+	balance, err := b.ledgerRepo.Balance(b.ctx, chatID, user.ID)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	reply(fmt.Sprintf(
+		lang.BalanceAdjusted,
+		user.Mention(),
+		fmt.Sprintf(lang.SignedAmount, amount, b.chatCurrency(chatID)),
+		reason,
+		b.formatAmount(chatID, balance),
+	))
+}
+
+// CurrencyCmd меняет название валюты наград чата: /currency баллов
+func (b *Botik) CurrencyCmd(chatID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /currency command", slog.String("error", err.Error()))
+		}
+	}
+
+	currency := strings.TrimSpace(args)
+	if currency == "" || len([]rune(currency)) > 16 {
+		reply(fmt.Sprintf(lang.CurrencyUsage, b.chatCurrency(chatID)))
+		return
+	}
+
+	err := b.chatRepo.SetCurrency(b.ctx, chatID, currency)
+	if errors.Is(err, repository.ErrChatNotFound) {
+		reply(lang.ChatNotInitialized)
+		return
+	}
+	if err != nil {
+		slog.Error("handle /currency command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	reply(fmt.Sprintf(lang.CurrencyChanged, currency))
+}
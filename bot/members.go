@@ -0,0 +1,311 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+func newEntityUser(u *tgbotapi.User) entity.User {
+	return entity.User{
+		ID:           u.ID,
+		Username:     u.UserName,
+		FirstName:    u.FirstName,
+		LastName:     u.LastName,
+		LanguageCode: u.LanguageCode,
+	}
+}
+
+// trackUser обновляет профиль и время последней активности пользователя, приславшего обновление
+func (b *Botik) trackUser(ctx context.Context, u *tgbotapi.User) {
+	if u == nil || u.IsBot {
+		return
+	}
+
+	user := newEntityUser(u)
+	user.LastSeenAt = time.Now()
+	b.saveUser(ctx, user)
+}
+
+// rememberUser кэширует профиль пользователя, полученный от Telegram не из его собственного действия
+func (b *Botik) rememberUser(ctx context.Context, u *tgbotapi.User) {
+	if u == nil || u.IsBot {
+		return
+	}
+
+	b.saveUser(ctx, newEntityUser(u))
+}
+
+// saveUser сохраняет профиль в справочник пользователей
+func (b *Botik) saveUser(ctx context.Context, user entity.User) {
+	if err := b.userRepo.Save(ctx, user); err != nil {
+		slog.Error("failed to save user", slog.Int64("user_id", user.ID), slog.String("error", err.Error()))
+	}
+}
+
+// chatMembers возвращает профили участников чата. Профили, которых нет в кэше, запрашиваются у Telegram
+func (b *Botik) chatMembers(ctx context.Context, chatID int64) ([]entity.User, error) {
+	chatMembers, err := b.chatRepo.Members(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("get chat members: %w", err)
+	}
+
+	ids := make([]int64, 0, len(chatMembers))
+	for _, member := range chatMembers {
+		ids = append(ids, member.UserID)
+	}
+
+	known, err := b.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get users: %w", err)
+	}
+
+	members := make([]entity.User, 0, len(ids))
+	for _, id := range ids {
+		user, ok := known[id]
+		if !ok {
+			member, err := b.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+				ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: id},
+			})
+			if err != nil {
+				slog.Warn("failed to get chat member", slog.Int64("user_id", id), slog.String("error", err.Error()))
+				user = entity.User{ID: id}
+			} else {
+				b.rememberUser(ctx, member.User)
+				user = newEntityUser(member.User)
+			}
+		}
+		if user.ID != b.bot.Self.ID {
+			members = append(members, user)
+		}
+	}
+
+	return members, nil
+}
+
+// assigneeMention упоминание исполнителя задания для карточки
+func (b *Botik) assigneeMention(ctx context.Context, task *entity.Task) string {
+	if task.AssigneeID == 0 {
+		return task.Assignee
+	}
+	return b.userMention(ctx, task.AssigneeID)
+}
+
+// userMention упоминание пользователя по ID. Неизвестный пользователь показывается номером
+func (b *Botik) userMention(ctx context.Context, userID int64) string {
+	user, err := b.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			slog.Error("failed to get user", slog.String("error", err.Error()))
+		}
+		user = entity.User{ID: userID}
+	}
+	return user.Mention()
+}
+
+// memberRole переводит статус участника Telegram в роль. ok == false, если пользователь не состоит в чате
+func memberRole(member tgbotapi.ChatMember) (role entity.MemberRole, ok bool) {
+	switch {
+	case member.IsCreator():
+		return entity.RoleOwner, true
+	case member.IsAdministrator():
+		return entity.RoleAdmin, true
+	case member.Status == "member":
+		return entity.RoleMember, true
+	case member.Status == "restricted" && member.IsMember:
+		// Участник, которому запрещено писать, может только смотреть задания
+		if !member.CanSendMessages {
+			return entity.RoleViewer, true
+		}
+		return entity.RoleMember, true
+	default:
+		return "", false
+	}
+}
+
+// handleChatMemberUpdate синхронизирует участника при вступлении, выходе, повышении или понижении.
+// Telegram присылает такие обновления, только если бот администратор чата
+func (b *Botik) handleChatMemberUpdate(upd *tgbotapi.ChatMemberUpdated) {
+	user := upd.NewChatMember.User
+	if user == nil || user.ID == b.bot.Self.ID {
+		return
+	}
+
+	role, ok := memberRole(upd.NewChatMember)
+	if !ok {
+		b.memberLeft(upd.Chat.ID, user)
+		return
+	}
+
+	b.memberJoined(upd.Chat.ID, user, role)
+}
+
+// handleMyChatMemberUpdate отслеживает добавление и удаление самого бота
+func (b *Botik) handleMyChatMemberUpdate(upd *tgbotapi.ChatMemberUpdated) {
+	_, ok := memberRole(upd.NewChatMember)
+	b.setChatActive(upd.Chat.ID, ok)
+}
+
+// memberJoined добавляет участника инициализированного чата, возвращает ушедшего или обновляет его роль в Telegram.
+// Роль, назначенная командой /role, сохраняется
+func (b *Botik) memberJoined(chatID int64, u *tgbotapi.User, role entity.MemberRole) {
+	if u.IsBot || !b.chatInitialized(chatID) {
+		return
+	}
+
+	b.rememberUser(b.ctx, u)
+
+	if err := b.chatRepo.SaveMember(b.ctx, entity.NewChatMember(chatID, u.ID, role)); err != nil {
+		slog.Error("failed to save chat member", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		return
+	}
+
+	b.linkLegacyAssignee(chatID, u)
+}
+
+// linkLegacyAssignee привязывает к участнику задания чата, у которых исполнитель записан текстом @username.
+// Задания со старым исполнителем, записанным по имени, привязывает администратор командой /link_assignee
+func (b *Botik) linkLegacyAssignee(chatID int64, u *tgbotapi.User) {
+	linked, err := b.taskRepo.LinkLegacyUsername(b.ctx, chatID, u.UserName, u.ID)
+	if err != nil {
+		slog.Error("failed to link legacy tasks", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		return
+	}
+	if linked > 0 {
+		slog.Info("linked legacy tasks to user", slog.Int64("chat_id", chatID), slog.Int64("user_id", u.ID), slog.Int64("tasks", linked))
+	}
+}
+
+// memberLeft отмечает уход участника и просит переназначить его незавершённые задания
+func (b *Botik) memberLeft(chatID int64, u *tgbotapi.User) {
+	if u.IsBot || !b.chatInitialized(chatID) {
+		return
+	}
+
+	if err := b.chatRepo.DeactivateMember(b.ctx, chatID, u.ID); err != nil {
+		slog.Error("failed to deactivate chat member", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		return
+	}
+
+	// Уход приходит и сервисным сообщением, и обновлением chat_member, повторно задания не отмечаются
+	flagged, err := b.taskRepo.FlagForReassignment(b.ctx, chatID, u.ID)
+	if err != nil {
+		slog.Error("failed to flag tasks for reassignment", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		return
+	}
+
+	if flagged > 0 {
+		text := fmt.Sprintf(lang.MemberLeftWithTasks, newEntityUser(u).Mention(), flagged)
+		b.enqueue(tgbotapi.NewMessage(chatID, text))
+	}
+}
+
+// setChatActive активирует или деактивирует чат, если он был инициализирован
+func (b *Botik) setChatActive(chatID int64, active bool) {
+	if !b.chatInitialized(chatID) {
+		return
+	}
+
+	if err := b.chatRepo.SetActive(b.ctx, chatID, active); err != nil {
+		slog.Error("failed to update chat state", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+	}
+}
+
+func (b *Botik) chatInitialized(chatID int64) bool {
+	_, err := b.chatRepo.GetByID(b.ctx, chatID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrChatNotFound) {
+			slog.Error("failed to get chat", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		}
+		return false
+	}
+	return true
+}
+
+// LinkAssigneeCmd привязывает задания, где исполнитель записан текстом, к участнику чата:
+// /link_assignee @username исполнитель
+func (b *Botik) LinkAssigneeCmd(chatID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /link_assignee command", slog.String("error", err.Error()))
+		}
+	}
+
+	username, assignee, _ := strings.Cut(strings.TrimSpace(args), " ")
+	assignee = strings.TrimSpace(assignee)
+	if username == "" || assignee == "" {
+		reply(lang.LinkAssigneeUsage)
+		return
+	}
+
+	user, err := b.userRepo.GetByUsername(b.ctx, username)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			reply(lang.UserNotFound)
+			return
+		}
+		slog.Error("handle /link_assignee command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	isMember, err := b.chatRepo.IsMember(b.ctx, chatID, user.ID)
+	if err != nil {
+		slog.Error("handle /link_assignee command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+	if !isMember {
+		reply(lang.NotChatMember)
+		return
+	}
+
+	linked, err := b.taskRepo.LinkLegacyAssignee(b.ctx, chatID, assignee, user.ID)
+	if err != nil {
+		slog.Error("handle /link_assignee command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+	if linked == 0 {
+		reply(fmt.Sprintf(lang.NoLegacyAssignee, assignee))
+		return
+	}
+
+	slog.Info("linked legacy tasks to user", slog.Int64("chat_id", chatID), slog.Int64("user_id", user.ID), slog.Int64("tasks", linked))
+	reply(fmt.Sprintf(lang.LegacyAssigneeLinked, assignee, user.Mention(), linked))
+}
+
+// AdoptTasksCmd переносит в чат задания, созданные до привязки к чатам.
+// Переносятся только задания, авторы которых состоят в этом чате
+func (b *Botik) AdoptTasksCmd(chatID int64, msgID int) {
+	if !b.chatInitialized(chatID) {
+		if err := b.sendText(chatID, lang.ChatNotInitialized, WithReply(msgID)); err != nil {
+			slog.Error("handle /adopt_tasks command", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	adopted, err := b.taskRepo.AdoptOrphans(b.ctx, chatID)
+	if err != nil {
+		slog.Error("handle /adopt_tasks command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	text := lang.NoOrphanTasks
+	if adopted > 0 {
+		slog.Info("adopted legacy tasks", slog.Int64("chat_id", chatID), slog.Int64("tasks", adopted))
+		text = fmt.Sprintf(lang.OrphanTasksAdopted, adopted)
+	}
+	if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+		slog.Error("handle /adopt_tasks command", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+func TestLegacyAssigneeLinkedWhenMemberSeen(t *testing.T) {
+	ctx := context.Background()
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+
+	for _, chatID := range []int64{testChatID, testChatID - 1} {
+		if err := b.chatRepo.Create(ctx, entity.NewChat(chatID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Задания со старым текстовым исполнителем, созданные до привязки исполнителей к пользователям
+	legacy := map[string]*entity.Task{
+		"username":       {ChatID: testChatID, Title: "Вынести мусор", Assignee: " @Petya "},
+		"name":           {ChatID: testChatID, Title: "Полить цветы", Assignee: "Петя"},
+		"other username": {ChatID: testChatID, Title: "Купить хлеб", Assignee: "@petya_k"},
+		"other chat":     {ChatID: testChatID - 1, Title: "Убрать кухню", Assignee: "@petya"},
+		"already linked": {ChatID: testChatID, Title: "Погулять с собакой", Assignee: "@petya", AssigneeID: testAdmin},
+	}
+	for _, task := range legacy {
+		task.Status, task.CreatedBy = entity.StatusOpen, testCreator
+		if err := b.taskRepo.Create(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Пользователь впервые появляется в чате
+	b.handleChatMemberUpdate(&tgbotapi.ChatMemberUpdated{
+		Chat: tgbotapi.Chat{ID: testChatID},
+		NewChatMember: tgbotapi.ChatMember{
+			User:   &tgbotapi.User{ID: testAssignee, UserName: "petya", FirstName: "Петя"},
+			Status: "member",
+		},
+	})
+
+	want := map[string]int64{
+		"username":       testAssignee,
+		"name":           0,
+		"other username": 0,
+		"other chat":     0,
+		"already linked": testAdmin,
+	}
+	for name, task := range legacy {
+		got, err := b.taskRepo.GetByID(ctx, task.ChatID, task.ID)
+		if err != nil || got == nil {
+			t.Fatalf("get task: %v", err)
+		}
+		if got.AssigneeID != want[name] {
+			t.Errorf("%s: assignee %d, want %d", name, got.AssigneeID, want[name])
+		}
+	}
+
+	// Привязанный исполнитель может выполнить задание
+	task, err := b.taskRepo.GetByID(ctx, testChatID, legacy["username"].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actor, err := b.actor(testChatID, testAssignee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := task.CanTransition(entity.StatusDone, actor, b.policy); err != nil {
+		t.Errorf("legacy assignee cannot complete task: %v", err)
+	}
+}
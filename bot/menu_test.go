@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+)
+
+func TestMenuCallback(t *testing.T) {
+	const viewer = 10
+
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+	ctx := context.Background()
+
+	if err := b.chatRepo.Create(ctx, entity.NewChat(testChatID)); err != nil {
+		t.Fatal(err)
+	}
+	for userID, role := range map[int64]entity.MemberRole{testCreator: entity.RoleMember, viewer: entity.RoleViewer} {
+		if err := b.chatRepo.SaveMember(ctx, entity.NewChatMember(testChatID, userID, role)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	press := func(userID int64, data string) error {
+		t.Helper()
+
+		_, err := b.callbacks.route(&tgbotapi.CallbackQuery{
+			Data:    data,
+			From:    &tgbotapi.User{ID: userID},
+			Message: &tgbotapi.Message{MessageID: 5, Chat: &tgbotapi.Chat{ID: testChatID}},
+		})
+		return err
+	}
+
+	// Кнопка «В меню» из списка заданий возвращает главное меню
+	if err := press(viewer, MenuCallback); err != nil {
+		t.Fatal(err)
+	}
+	if text := lastCall(t, api, "editMessageText").Params.Get("text"); text != lang.MainMenu {
+		t.Errorf("menu text %q, want %q", text, lang.MainMenu)
+	}
+
+	// Создавать задания из меню может только тот, кому разрешено создание
+	if err := press(viewer, callbackData(MenuCallback, menuNew)); !errors.Is(err, ErrForbidden) {
+		t.Errorf("viewer creating task: %v, want ErrForbidden", err)
+	}
+	if err := press(testCreator, callbackData(MenuCallback, menuNew)); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := b.convRepo.Get(ctx, testChatID, testCreator)
+	if err != nil || conv.State != stateWaitingTitle {
+		t.Errorf("conversation %+v, %v, want task creation started", conv, err)
+	}
+
+	if err := press(testCreator, callbackData(MenuCallback, "unknown")); !errors.Is(err, ErrMalformedCallback) {
+		t.Errorf("unknown menu item: %v, want ErrMalformedCallback", err)
+	}
+}
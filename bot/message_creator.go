@@ -1,5 +1,143 @@
 package bot
 
-func (b *Botik) createTaskListMessage() {
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const ListCallback = "list"
+
+// Направления листания списка заданий в кнопке "list:<направление>". Другой аргумент открывает первую страницу
+const (
+	listPrev = "prev"
+	listNext = "next"
+)
+
+// taskListPageSize Сколько заданий показывать на одной странице списка
+const taskListPageSize = 5
+
+// Слова фильтров команды /tasks, остальные слова ищутся в названии и описании
+const (
+	filterMine   = "мои"
+	filterActive = "активные"
+	filterDue    = "срок"
+)
+
+// parseTaskFilter разбирает аргументы /tasks в фильтр списка заданий чата
+func parseTaskFilter(chatID, userID int64, args string) repository.TaskFilter {
+	filter := repository.TaskFilter{ChatID: chatID}
+
+	var search []string
+	for _, word := range strings.Fields(args) {
+		switch strings.ToLower(word) {
+		case filterMine:
+			filter.AssigneeID = userID
+		case filterActive:
+			filter.Statuses = []entity.TaskStatus{entity.StatusOpen, entity.StatusInProgress}
+		case filterDue:
+			filter.Sort = repository.SortDueAsc
+		default:
+			search = append(search, word)
+		}
+	}
+	filter.Search = strings.Join(search, " ")
+
+	return filter
+}
+
+// filtered true, если список ограничен фильтром или поиском
+func filtered(filter repository.TaskFilter) bool {
+	return filter.AssigneeID != 0 || len(filter.Statuses) > 0 || filter.Search != ""
+}
+
+// createTaskListMessage текст и кнопки текущей страницы списка и курсор следующей страницы.
+// Повреждённый курсор или опустевшая страница заменяются первой страницей
+func (b *Botik) createTaskListMessage(list *repository.TaskList) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	markup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+
+	filter := list.Filter
+	filter.Limit = taskListPageSize
+	filter.Cursor = list.Cursor()
+
+	page, err := b.taskRepo.Query(b.ctx, filter)
+	if errors.Is(err, repository.ErrInvalidCursor) || (err == nil && len(page.Tasks) == 0 && filter.Cursor != "") {
+		list.Cursors = []string{""}
+		filter.Cursor = ""
+		page, err = b.taskRepo.Query(b.ctx, filter)
+	}
+	if err != nil {
+		return "", markup, fmt.Errorf("query tasks: %w", err)
+	}
+	list.Next = page.NextCursor
+
+	if len(page.Tasks) == 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, menuRow())
+		if filtered(list.Filter) {
+			return lang.NoTasksFound, markup, nil
+		}
+		return lang.NoTasks, markup, nil
+	}
+
+	ids := make([]int64, 0, len(page.Tasks))
+	for _, task := range page.Tasks {
+		if task.AssigneeID != 0 {
+			ids = append(ids, task.AssigneeID)
+		}
+	}
+	users, err := b.userRepo.GetByIDs(b.ctx, ids)
+	if err != nil {
+		return "", markup, fmt.Errorf("get assignees: %w", err)
+	}
+
+	header := lang.TaskListHeader
+	if filtered(list.Filter) {
+		header = lang.TaskListFilteredHeader
+	}
+	lines := []string{fmt.Sprintf(header, len(list.Cursors))}
+	for _, task := range page.Tasks {
+		lines = append(lines, fmt.Sprintf(lang.TaskListLine, task.ID, task.Title, statusNames[task.Status], listAssignee(task, users)))
+		markup.InlineKeyboard = append(markup.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf(lang.TaskListButton, task.ID, task.Title),
+				callbackData(TaskCallback, strconv.FormatInt(task.ID, 10)),
+			),
+		))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if len(list.Cursors) > 1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(lang.ButtonPrevPage, callbackData(ListCallback, listPrev)))
+	}
+	if list.Next != "" {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(lang.ButtonNextPage, callbackData(ListCallback, listNext)))
+	}
+	if len(nav) > 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, nav)
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, menuRow())
+
+	return strings.Join(lines, "\n"), markup, nil
+}
+
+// listAssignee имя исполнителя для строки списка
+func listAssignee(task *entity.Task, users map[int64]entity.User) string {
+	if task.AssigneeID == 0 {
+		if task.Assignee != "" {
+			return task.Assignee
+		}
+		return lang.NoAssignee
+	}
+
+	user, ok := users[task.AssigneeID]
+	if !ok {
+		user = entity.User{ID: task.AssigneeID}
+	}
+	return user.DisplayName()
 }
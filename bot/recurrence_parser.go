@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+// Дни недели во множественном числе: "по вторникам и четвергам"
+var weekdayPluralNames = map[string]time.Weekday{
+	"понедельникам": time.Monday,
+	"вторникам":     time.Tuesday,
+	"средам":        time.Wednesday,
+	"четвергам":     time.Thursday,
+	"пятницам":      time.Friday,
+	"субботам":      time.Saturday,
+	"воскресеньям":  time.Sunday,
+}
+
+// Слова, которые не меняют правило повторения
+var recurrenceFillers = map[string]bool{
+	"каждый": true, "каждую": true, "каждое": true, "каждые": true, "каждого": true, "каждых": true,
+	"по": true, "в": true, "во": true, "и": true, "раз": true, "после": true,
+}
+
+// recurrenceSpec Правило повторения, записанное пользователем, и время суток, если оно указано
+type recurrenceSpec struct {
+	Rule         entity.Recurrence
+	Hour, Minute int
+	HasTime      bool
+}
+
+// parseRecurrence разбирает правило повторения, записанное по-русски: "каждый день", "каждые 3 дня",
+// "по вторникам и четвергам в 10:00", "каждый месяц 15 числа", "через 2 дня после выполнения"
+func parseRecurrence(input string) (recurrenceSpec, error) {
+	var (
+		spec      recurrenceSpec
+		freq      entity.RecurrenceFreq
+		interval  int
+		monthDay  int
+		weekdays  []time.Weekday
+		afterDone bool
+		every     bool // "через день"
+	)
+
+	tokens := dateTokens(input)
+	if len(tokens) == 0 {
+		return recurrenceSpec{}, ErrUnknownDate
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		weekday, isWeekday := weekdayNames[tok]
+		if !isWeekday {
+			weekday, isWeekday = weekdayPluralNames[tok]
+		}
+
+		switch {
+		case recurrenceFillers[tok]:
+		case strings.HasPrefix(tok, "выполн"):
+			afterDone = true
+		case tok == "через":
+			every = true
+		case isWeekday:
+			if !slices.Contains(weekdays, weekday) {
+				weekdays = append(weekdays, weekday)
+			}
+		case tok == "ежедневно", strings.HasPrefix(tok, "дн"), strings.HasPrefix(tok, "ден"):
+			freq = entity.FreqDaily
+		case tok == "еженедельно", strings.HasPrefix(tok, "недел"):
+			freq = entity.FreqWeekly
+		case tok == "ежемесячно", strings.HasPrefix(tok, "месяц"):
+			freq = entity.FreqMonthly
+		case clockRe.MatchString(tok):
+			m := clockRe.FindStringSubmatch(tok)
+			spec.Hour, _ = strconv.Atoi(m[1])
+			spec.Minute, _ = strconv.Atoi(m[2])
+			if spec.HasTime || spec.Hour > 23 || spec.Minute > 59 {
+				return recurrenceSpec{}, ErrUnknownDate
+			}
+			spec.HasTime = true
+		default:
+			n, err := strconv.Atoi(tok)
+			if err != nil {
+				n = numberWords[tok]
+			}
+			if n <= 0 {
+				return recurrenceSpec{}, ErrUnknownDate
+			}
+
+			if next == "числа" {
+				i++
+				monthDay = n
+				continue
+			}
+			if interval != 0 {
+				return recurrenceSpec{}, ErrUnknownDate
+			}
+			interval = n
+		}
+	}
+
+	if interval == 0 {
+		interval = 1
+	}
+
+	switch {
+	case afterDone:
+		// "через 2 дня после выполнения", "через неделю после выполнения"
+		if !every || len(weekdays) > 0 || monthDay != 0 {
+			return recurrenceSpec{}, ErrUnknownDate
+		}
+		switch freq {
+		case entity.FreqDaily:
+		case entity.FreqWeekly:
+			interval *= 7
+		default:
+			return recurrenceSpec{}, ErrUnknownDate
+		}
+		spec.Rule = entity.Recurrence{Freq: entity.FreqDaily, Interval: interval, AfterCompletion: true}
+	case every:
+		// "через день" означает каждый второй день
+		if freq != entity.FreqDaily || interval != 1 {
+			return recurrenceSpec{}, ErrUnknownDate
+		}
+		spec.Rule = entity.Recurrence{Freq: entity.FreqDaily, Interval: 2}
+	case len(weekdays) > 0:
+		if (freq != "" && freq != entity.FreqWeekly) || monthDay != 0 {
+			return recurrenceSpec{}, ErrUnknownDate
+		}
+		slices.Sort(weekdays)
+		spec.Rule = entity.Recurrence{Freq: entity.FreqWeekly, Interval: interval, Weekdays: weekdays}
+	case monthDay != 0 || freq == entity.FreqMonthly:
+		if freq != "" && freq != entity.FreqMonthly {
+			return recurrenceSpec{}, ErrUnknownDate
+		}
+		spec.Rule = entity.Recurrence{Freq: entity.FreqMonthly, Interval: interval, MonthDay: monthDay}
+	case freq != "":
+		spec.Rule = entity.Recurrence{Freq: freq, Interval: interval}
+	default:
+		return recurrenceSpec{}, ErrUnknownDate
+	}
+
+	if err := spec.Rule.Validate(); err != nil {
+		return recurrenceSpec{}, err
+	}
+	return spec, nil
+}
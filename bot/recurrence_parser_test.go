@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		input string
+		rule  entity.Recurrence
+		time  string // Время суток, если указано
+	}{
+		{input: "каждый день", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 1}},
+		{input: "Ежедневно в 9:00", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 1}, time: "09:00"},
+		{input: "каждые 3 дня", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 3}},
+		{input: "каждые пять дней в 21:30", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 5}, time: "21:30"},
+		{input: "через день", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 2}},
+		{input: "каждую неделю", rule: entity.Recurrence{Freq: entity.FreqWeekly, Interval: 1}},
+		{input: "еженедельно", rule: entity.Recurrence{Freq: entity.FreqWeekly, Interval: 1}},
+		{input: "каждые 2 недели", rule: entity.Recurrence{Freq: entity.FreqWeekly, Interval: 2}},
+		{input: "каждый вторник", rule: entity.Recurrence{Freq: entity.FreqWeekly, Interval: 1, Weekdays: []time.Weekday{time.Tuesday}}},
+		{
+			input: "по вторникам и четвергам в 10:00",
+			rule:  entity.Recurrence{Freq: entity.FreqWeekly, Interval: 1, Weekdays: []time.Weekday{time.Tuesday, time.Thursday}},
+			time:  "10:00",
+		},
+		{
+			input: "по пятницам, понедельникам и пятницам",
+			rule:  entity.Recurrence{Freq: entity.FreqWeekly, Interval: 1, Weekdays: []time.Weekday{time.Monday, time.Friday}},
+		},
+		{
+			input: "каждые две недели по воскресеньям",
+			rule:  entity.Recurrence{Freq: entity.FreqWeekly, Interval: 2, Weekdays: []time.Weekday{time.Sunday}},
+		},
+		{input: "каждый месяц", rule: entity.Recurrence{Freq: entity.FreqMonthly, Interval: 1}},
+		{input: "ежемесячно 15 числа в 12:00", rule: entity.Recurrence{Freq: entity.FreqMonthly, Interval: 1, MonthDay: 15}, time: "12:00"},
+		{input: "каждый месяц 31 числа", rule: entity.Recurrence{Freq: entity.FreqMonthly, Interval: 1, MonthDay: 31}},
+		{input: "каждые 3 месяца 1 числа", rule: entity.Recurrence{Freq: entity.FreqMonthly, Interval: 3, MonthDay: 1}},
+		{input: "15 числа", rule: entity.Recurrence{Freq: entity.FreqMonthly, Interval: 1, MonthDay: 15}},
+		{input: "через 2 дня после выполнения", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 2, AfterCompletion: true}},
+		{input: "через день после выполнения", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 1, AfterCompletion: true}},
+		{input: "через неделю после выполнения", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 7, AfterCompletion: true}},
+		{input: "через 2 недели после выполнения в 8:00", rule: entity.Recurrence{Freq: entity.FreqDaily, Interval: 14, AfterCompletion: true}, time: "08:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			spec, err := parseRecurrence(tt.input)
+			if err != nil {
+				t.Fatalf("parseRecurrence(%q) error: %v", tt.input, err)
+			}
+			if spec.Rule.String() != tt.rule.String() {
+				t.Errorf("parseRecurrence(%q) rule = %s, want %s", tt.input, spec.Rule, tt.rule)
+			}
+
+			var gotTime string
+			if spec.HasTime {
+				gotTime = time.Date(0, 1, 1, spec.Hour, spec.Minute, 0, 0, time.UTC).Format("15:04")
+			}
+			if gotTime != tt.time {
+				t.Errorf("parseRecurrence(%q) time = %q, want %q", tt.input, gotTime, tt.time)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"иногда",
+		"каждые",
+		"каждые 0 дней",
+		"каждые 2 3 дня",
+		"через 2 дня",
+		"через неделю",
+		"каждый день после выполнения",
+		"через месяц после выполнения",
+		"через 2 дня после выполнения по вторникам",
+		"каждый день по вторникам",
+		"каждый месяц по вторникам",
+		"по вторникам 15 числа",
+		"каждую неделю 15 числа",
+		"каждый день в 25:00",
+		"каждый день в 10:00 в 11:00",
+		"32 числа",
+	} {
+		t.Run(input, func(t *testing.T) {
+			if spec, err := parseRecurrence(input); err == nil {
+				t.Errorf("parseRecurrence(%q) = %s, want error", input, spec.Rule)
+			}
+		})
+	}
+}
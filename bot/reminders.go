@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+// Вид напоминания о задании, срок которого уже прошёл
+const reminderOverdue = "overdue"
+
+// Формат, в котором сроки показываются пользователям
+const dueLayout = "02.01.2006 15:04"
+
+// reminderNotifier доставляет напоминание о задании. overdue == true, если срок уже прошёл
+type reminderNotifier func(ctx context.Context, task *entity.Task, overdue bool) error
+
+// reminderJob отправляет напоминания о сроках заданий. Отправленные напоминания
+// записываются в базу, поэтому после перезапуска они не повторяются
+type reminderJob struct {
+	offsets   []time.Duration // По возрастанию
+	tasks     repository.TaskRepository
+	reminders repository.ReminderRepository
+	notify    reminderNotifier
+}
+
+func newReminderJob(
+	offsets []time.Duration,
+	tasks repository.TaskRepository,
+	reminders repository.ReminderRepository,
+	notify reminderNotifier,
+) (*reminderJob, error) {
+	sorted := slices.Clone(offsets)
+	for _, offset := range sorted {
+		if offset <= 0 {
+			return nil, fmt.Errorf("reminder offset must be positive, got %s", offset)
+		}
+	}
+	slices.Sort(sorted)
+
+	return &reminderJob{
+		offsets:   slices.Compact(sorted),
+		tasks:     tasks,
+		reminders: reminders,
+		notify:    notify,
+	}, nil
+}
+
+// run отправляет напоминания, время которых наступило к моменту now
+func (s *reminderJob) run(ctx context.Context, now time.Time) {
+	var horizon time.Duration
+	if len(s.offsets) > 0 {
+		horizon = s.offsets[len(s.offsets)-1]
+	}
+
+	tasks, err := s.tasks.DueBefore(ctx, now.Add(horizon))
+	if err != nil {
+		slog.Error("failed to get tasks with deadlines", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, task := range tasks {
+		kind, ok := s.reminderKind(task, now)
+		if !ok {
+			continue
+		}
+
+		// Напоминание отмечается до отправки, чтобы его не отправили дважды
+		claimed, err := s.reminders.Claim(ctx, task.ID, kind, task.DueAt)
+		if err != nil {
+			slog.Error("failed to claim reminder", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.notify(ctx, task, kind == reminderOverdue); err != nil {
+			slog.Error("failed to send reminder", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+
+			// Повтор не поможет, если бота удалили из чата или чата больше нет
+			if isPermanentSendError(err) {
+				if err := s.reminders.Drop(ctx, task.ID, kind, task.DueAt); err != nil {
+					slog.Error("failed to drop reminder", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+				}
+				continue
+			}
+
+			if err := s.reminders.Release(ctx, task.ID, kind, task.DueAt); err != nil {
+				slog.Error("failed to release reminder", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// reminderKind выбирает напоминание, время которого наступило. Из нескольких пропущенных
+// напоминаний, например пока бот был выключен, отправляется только ближайшее к сроку
+func (s *reminderJob) reminderKind(task *entity.Task, now time.Time) (string, bool) {
+	if !now.Before(task.DueAt) {
+		return reminderOverdue, true
+	}
+
+	for _, offset := range s.offsets {
+		if !now.Before(task.DueAt.Add(-offset)) {
+			return offset.String(), true
+		}
+	}
+	return "", false
+}
+
+// sendReminder напоминает о сроке задания в чате или, если так настроено, исполнителю в личные сообщения
+func (b *Botik) sendReminder(ctx context.Context, task *entity.Task, overdue bool) error {
+	text := fmt.Sprintf(lang.ReminderBeforeDue, task.ID, task.Title, b.formatDue(task.ChatID, task.DueAt))
+	if overdue {
+		text = fmt.Sprintf(lang.ReminderOverdue, task.ID, task.Title, b.formatDue(task.ChatID, task.DueAt))
+	}
+
+	if b.cfg.Reminders.Private && task.AssigneeID != 0 {
+		_, err := b.bot.Send(tgbotapi.NewMessage(task.AssigneeID, text))
+		if err == nil {
+			return nil
+		}
+
+		// Бот не может писать пользователю, который не начинал с ним диалог
+		slog.Warn(
+			"failed to send private reminder, falling back to chat",
+			slog.Int64("task_id", task.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	if mention := b.assigneeMention(ctx, task); mention != "" {
+		text = mention + "\n" + text
+	}
+
+	sent, err := b.bot.Send(tgbotapi.NewMessage(task.ChatID, text))
+	if err != nil {
+		return err
+	}
+
+	b.linkTaskMessage(task.ID, task.ChatID, sent.MessageID)
+	return nil
+}
+
+// formatDue форматирует срок в часовом поясе чата
+func (b *Botik) formatDue(chatID int64, t time.Time) string {
+	return t.In(b.chatLocation(chatID)).Format(dueLayout)
+}
@@ -0,0 +1,189 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/repository"
+)
+
+// sentReminder напоминание, переданное на доставку
+type sentReminder struct {
+	TaskID  int64
+	Overdue bool
+	At      time.Time
+}
+
+func TestReminderJobClaimsAndReleases(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	tasks := repository.NewTaskRepositoryImpl(db)
+	reminders := repository.NewReminderRepositoryImpl(db)
+
+	if err := repository.NewChatRepositoryImpl(db).Create(ctx, entity.NewChat(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	task := &entity.Task{ChatID: 1, Title: "Полить цветы", Status: entity.StatusOpen, DueAt: start.Add(90 * time.Minute), CreatedBy: 7}
+	if err := tasks.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		clock = newFakeClock(start)
+		sent  []sentReminder
+		fail  bool
+	)
+	notify := func(_ context.Context, task *entity.Task, overdue bool) error {
+		if fail {
+			fail = false
+			return errors.New("telegram is unavailable")
+		}
+		sent = append(sent, sentReminder{TaskID: task.ID, Overdue: overdue, At: clock.Now()})
+		return nil
+	}
+
+	job, err := newReminderJob([]time.Duration{time.Hour, 24 * time.Hour, time.Hour}, tasks, reminders, notify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := startScheduler(t, clock, job.run)
+
+	expect := func(want ...sentReminder) {
+		t.Helper()
+		if len(sent) != len(want) {
+			t.Fatalf("sent reminders %+v, want %+v", sent, want)
+		}
+		for i := range want {
+			if sent[i].TaskID != want[i].TaskID || sent[i].Overdue != want[i].Overdue || !sent[i].At.Equal(want[i].At) {
+				t.Fatalf("sent reminders %+v, want %+v", sent, want)
+			}
+		}
+	}
+
+	// До срока меньше суток: напоминание за 24 часа отправляется сразу
+	day := sentReminder{TaskID: task.ID, At: start}
+	expect(day)
+
+	// Повторный шаг не отправляет то же напоминание
+	step(10 * time.Minute)
+	expect(day)
+
+	// Напоминание за час не доставлено, отметка снимается
+	fail = true
+	step(25 * time.Minute)
+	expect(day)
+
+	// На следующем шаге оно отправляется повторно
+	hour := sentReminder{TaskID: task.ID, At: step(time.Minute)}
+	expect(day, hour)
+
+	step(10 * time.Minute)
+	expect(day, hour)
+
+	// Срок прошёл
+	overdue := sentReminder{TaskID: task.ID, Overdue: true, At: step(time.Hour)}
+	expect(day, hour, overdue)
+
+	// Отправленные напоминания хранятся в базе и не повторяются после перезапуска
+	restarted, err := newReminderJob([]time.Duration{24 * time.Hour, time.Hour}, tasks, reminders, notify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted.run(ctx, clock.Now())
+	expect(day, hour, overdue)
+}
+
+func TestReminderJobSendsOnlyClosestMissedReminder(t *testing.T) {
+	job, err := newReminderJob([]time.Duration{24 * time.Hour, time.Hour}, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+	task := &entity.Task{DueAt: due}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		kind string
+		ok   bool
+	}{
+		{name: "before any reminder", now: due.Add(-25 * time.Hour)},
+		{name: "day before", now: due.Add(-24 * time.Hour), kind: "24h0m0s", ok: true},
+		{name: "both missed", now: due.Add(-30 * time.Minute), kind: "1h0m0s", ok: true},
+		{name: "due now", now: due, kind: reminderOverdue, ok: true},
+		{name: "overdue", now: due.Add(48 * time.Hour), kind: reminderOverdue, ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, ok := job.reminderKind(task, tt.now)
+			if kind != tt.kind || ok != tt.ok {
+				t.Errorf("reminderKind() = %q, %v, want %q, %v", kind, ok, tt.kind, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewReminderJobRejectsNonPositiveOffset(t *testing.T) {
+	if _, err := newReminderJob([]time.Duration{time.Hour, 0}, nil, nil, nil); err == nil {
+		t.Error("newReminderJob accepted zero offset")
+	}
+}
+
+func TestReminderJobDropsUndeliverableReminders(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		retried bool
+	}{
+		{name: "bot removed", err: &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was kicked from the group chat"}},
+		{name: "chat not found", err: &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}},
+		{name: "flood", err: &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 5"}, retried: true},
+		{name: "network", err: errors.New("connection reset by peer"), retried: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			tasks := repository.NewTaskRepositoryImpl(db)
+
+			if err := repository.NewChatRepositoryImpl(db).Create(ctx, entity.NewChat(1)); err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+			task := &entity.Task{ChatID: 1, Title: "Полить цветы", Status: entity.StatusOpen, DueAt: start.Add(-time.Hour), CreatedBy: 7}
+			if err := tasks.Create(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+
+			var attempts int
+			notify := func(context.Context, *entity.Task, bool) error {
+				attempts++
+				return tt.err
+			}
+			job, err := newReminderJob([]time.Duration{time.Hour}, tasks, repository.NewReminderRepositoryImpl(db), notify)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			job.run(ctx, start)
+			job.run(ctx, start.Add(time.Minute))
+
+			want := 1
+			if tt.retried {
+				want = 2
+			}
+			if attempts != want {
+				t.Errorf("notify called %d times, want %d", attempts, want)
+			}
+		})
+	}
+}
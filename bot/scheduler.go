@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Clock источник текущего времени и тиков планировщика. В тестах подменяется, чтобы управлять планировщиком
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker периодически присылает время в канал C
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{ticker: time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t systemTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// scheduledJob фоновая работа, которую планировщик запускает на каждом шаге
+type scheduledJob func(ctx context.Context, now time.Time)
+
+// scheduler периодически запускает фоновые работы: напоминания о сроках, создание повторяющихся заданий
+type scheduler struct {
+	clock    Clock
+	interval time.Duration
+	jobs     []scheduledJob
+}
+
+func newScheduler(clock Clock, interval time.Duration, jobs ...scheduledJob) (*scheduler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("scheduler interval must be positive, got %s", interval)
+	}
+	return &scheduler{clock: clock, interval: interval, jobs: jobs}, nil
+}
+
+// run запускает работы сразу и затем раз в interval, пока не закрыт stop
+func (s *scheduler) run(ctx context.Context, stop <-chan struct{}) {
+	ticker := s.clock.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+func (s *scheduler) tick(ctx context.Context) {
+	now := s.clock.Now()
+	for _, job := range s.jobs {
+		job(ctx, now)
+	}
+}
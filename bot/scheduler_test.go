@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const testInterval = time.Minute
+
+// startScheduler запускает планировщик на поддельных часах и дожидается первого шага.
+// Возвращаемая функция двигает часы на d и ждёт, пока все работы шага выполнятся
+func startScheduler(t *testing.T, clock *fakeClock, jobs ...scheduledJob) func(d time.Duration) time.Time {
+	t.Helper()
+
+	ticked := make(chan time.Time)
+	jobs = append(jobs, func(_ context.Context, now time.Time) { ticked <- now })
+
+	s, err := newScheduler(clock, testInterval, jobs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(context.Background(), stop)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+
+	wait := func() time.Time {
+		t.Helper()
+		select {
+		case now := <-ticked:
+			return now
+		case <-time.After(5 * time.Second):
+			t.Fatal("scheduler did not run jobs")
+			return time.Time{}
+		}
+	}
+	wait()
+
+	return func(d time.Duration) time.Time {
+		t.Helper()
+		clock.Advance(d)
+		return wait()
+	}
+}
+
+func TestSchedulerRunsJobsOnEveryTick(t *testing.T) {
+	start := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+
+	var got []string
+	first := func(_ context.Context, now time.Time) { got = append(got, "first "+now.Format("15:04")) }
+	second := func(_ context.Context, now time.Time) { got = append(got, "second "+now.Format("15:04")) }
+
+	step := startScheduler(t, clock, first, second)
+
+	if now := step(testInterval); !now.Equal(start.Add(testInterval)) {
+		t.Fatalf("job got time %s, want %s", now, start.Add(testInterval))
+	}
+	step(2 * testInterval)
+
+	want := []string{"first 09:00", "second 09:00", "first 09:01", "second 09:01", "first 09:03", "second 09:03"}
+	if len(got) != len(want) {
+		t.Fatalf("jobs ran %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("jobs ran %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSchedulerStopsOnStop(t *testing.T) {
+	s, err := newScheduler(newFakeClock(time.Now()), testInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(context.Background(), stop)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
+
+func TestNewSchedulerRejectsInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := newScheduler(systemClock{}, interval); err == nil {
+			t.Errorf("newScheduler(%s) returned no error", interval)
+		}
+	}
+}
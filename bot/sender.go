@@ -1,11 +1,19 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/lang"
 )
 
+// Размер очереди уведомлений, отправляемых в фоне
+const outboxSize = 100
+
 // MessageOption определяет тип функции-опции
 type MessageOption func(*tgbotapi.MessageConfig)
 
@@ -23,7 +31,20 @@ func WithParseMode(mode string) MessageOption {
 	}
 }
 
+// WithKeyboard добавляет к сообщению клавиатуру
+func WithKeyboard(markup interface{}) MessageOption {
+	return func(msg *tgbotapi.MessageConfig) {
+		msg.ReplyMarkup = markup
+	}
+}
+
 func (b *Botik) sendText(chatID int64, text string, opts ...MessageOption) error {
+	_, err := b.sendMessage(chatID, text, opts...)
+	return err
+}
+
+// sendMessage отправляет сообщение и возвращает его, когда нужен ID отправленного сообщения
+func (b *Botik) sendMessage(chatID int64, text string, opts ...MessageOption) (tgbotapi.Message, error) {
 	msg := tgbotapi.NewMessage(chatID, text)
 
 	// Применяем все переданные опции
@@ -31,9 +52,47 @@ func (b *Botik) sendText(chatID int64, text string, opts ...MessageOption) error
 		opt(&msg)
 	}
 
-	if _, err := b.bot.Send(msg); err != nil {
-		return fmt.Errorf("sending message: %w", err)
+	sent, err := b.bot.Send(msg)
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("sending message: %w", err)
+	}
+
+	return sent, nil
+}
+
+// isPermanentSendError проверяет, что Telegram не доставит сообщение и при повторе:
+// бота удалили или заблокировали в чате или чата больше нет
+func isPermanentSendError(err error) bool {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+	return tgErr.Code == http.StatusForbidden || strings.Contains(strings.ToLower(tgErr.Message), "chat not found")
+}
+
+// sendFailedStub сообщает пользователю, что запрос не удалось выполнить
+func (b *Botik) sendFailedStub(chatID int64, msgID int) {
+	if err := b.sendText(chatID, lang.FailedStub, WithReply(msgID)); err != nil {
+		slog.Error(err.Error())
+	}
+}
+
+// enqueue ставит сообщение в очередь фоновой отправки, не дожидаясь ответа Telegram
+func (b *Botik) enqueue(msg tgbotapi.Chattable) {
+	select {
+	case b.outbox <- msg:
+	case <-b.ctx.Done():
+		slog.Warn("dropping outgoing message: bot is stopped")
 	}
+}
 
-	return nil
+// sendQueued отправляет сообщения из очереди, пока она не будет закрыта и опустошена
+func (b *Botik) sendQueued() {
+	defer close(b.outboxDone)
+
+	for msg := range b.outbox {
+		if _, err := b.bot.Send(msg); err != nil {
+			slog.Error("failed to send queued message", slog.String("error", err.Error()))
+		}
+	}
 }
@@ -0,0 +1,436 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const SeriesCallback = "series"
+
+// Действия кнопок списка серий
+const (
+	seriesPause  = "pause"
+	seriesResume = "resume"
+	seriesEnd    = "end"
+)
+
+// describeRecurrence описывает правило повторения для пользователя
+func describeRecurrence(rule entity.Recurrence, start time.Time) string {
+	var parts []string
+
+	switch {
+	case rule.AfterCompletion:
+		parts = append(parts, fmt.Sprintf(lang.RecurAfterCompletion, rule.Interval))
+	case rule.Freq == entity.FreqDaily && rule.Interval == 1:
+		parts = append(parts, lang.RecurDaily)
+	case rule.Freq == entity.FreqDaily:
+		parts = append(parts, fmt.Sprintf(lang.RecurEveryNDays, rule.Interval))
+	case rule.Freq == entity.FreqWeekly:
+		if rule.Interval == 1 {
+			parts = append(parts, lang.RecurWeekly)
+		} else {
+			parts = append(parts, fmt.Sprintf(lang.RecurEveryNWeeks, rule.Interval))
+		}
+
+		weekdays := rule.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+		names := make([]string, 0, len(weekdays))
+		for _, wd := range weekdays {
+			names = append(names, lang.WeekdaysShort[wd])
+		}
+		parts = append(parts, strings.Join(names, ", "))
+	case rule.Freq == entity.FreqMonthly:
+		if rule.Interval == 1 {
+			parts = append(parts, lang.RecurMonthly)
+		} else {
+			parts = append(parts, fmt.Sprintf(lang.RecurEveryNMonths, rule.Interval))
+		}
+
+		monthDay := rule.MonthDay
+		if monthDay == 0 {
+			monthDay = start.Day()
+		}
+		parts = append(parts, fmt.Sprintf(lang.RecurMonthDay, monthDay))
+	}
+
+	parts = append(parts, fmt.Sprintf(lang.RecurAt, start.Format("15:04")))
+	return strings.Join(parts, ", ")
+}
+
+// advanceAllSeries создаёт следующие задания активных серий, период которых прошёл
+func (b *Botik) advanceAllSeries(ctx context.Context, now time.Time) {
+	list, err := b.seriesRepo.ListActive(ctx)
+	if err != nil {
+		slog.Error("failed to list task series", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, series := range list {
+		if err := b.advanceSeries(ctx, series, now); err != nil {
+			slog.Error("failed to advance task series", slog.Int64("series_id", series.ID), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// continueSeries создаёт следующее задание серии сразу после выполнения текущего
+func (b *Botik) continueSeries(task *entity.Task) {
+	series, err := b.seriesRepo.GetByID(b.ctx, task.ChatID, task.SeriesID)
+	if err != nil {
+		slog.Error("failed to get task series", slog.Int64("series_id", task.SeriesID), slog.String("error", err.Error()))
+		return
+	}
+
+	if err := b.advanceSeries(b.ctx, series, time.Now()); err != nil {
+		slog.Error("failed to advance task series", slog.Int64("series_id", series.ID), slog.String("error", err.Error()))
+	}
+}
+
+// advanceSeries создаёт следующее задание серии, если последнее выполнено или его срок прошёл.
+// Серии по календарю продолжаются со следующего периода, серии после выполнения — через интервал от now
+func (b *Botik) advanceSeries(ctx context.Context, series entity.Series, now time.Time) error {
+	if series.Status != entity.SeriesActive {
+		return nil
+	}
+
+	chat, err := b.chatRepo.GetByID(ctx, series.ChatID)
+	if err != nil {
+		return fmt.Errorf("get chat: %w", err)
+	}
+	if !chat.Active {
+		return nil
+	}
+
+	last, err := b.taskRepo.GetByID(ctx, series.ChatID, series.LastTaskID)
+	if err != nil {
+		return fmt.Errorf("get last series task: %w", err)
+	}
+
+	start := series.StartAt.In(b.chatLocation(series.ChatID))
+
+	var dueAt time.Time
+	switch {
+	case last == nil:
+		// Последнее задание удалили, серия продолжается с ближайшего вхождения
+		dueAt = series.Rule.Next(start, now)
+	case series.Rule.AfterCompletion:
+		if !last.IsCompleted() {
+			return nil
+		}
+		dueAt = series.Rule.Next(start, now)
+	case last.IsCompleted() || !now.Before(last.DueAt):
+		dueAt = series.Rule.Next(start, last.DueAt)
+		if !dueAt.After(now) {
+			dueAt = series.Rule.Next(start, now)
+		}
+	default:
+		return nil
+	}
+
+	task := series.NextTask(dueAt)
+	task.AutoComplete = last != nil && last.AutoComplete
+	if err := b.taskRepo.Create(ctx, task); err != nil {
+		return fmt.Errorf("create series task: %w", err)
+	}
+
+	if err := b.seriesRepo.Advance(ctx, series.ID, series.LastTaskID, task.ID); err != nil {
+		// Следующее задание уже создано, лишнее удаляем
+		if err := b.taskRepo.Delete(ctx, task.ChatID, task.ID); err != nil {
+			slog.Error("failed to delete duplicate series task", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+		}
+		if errors.Is(err, repository.ErrSeriesConflict) {
+			return nil
+		}
+		return fmt.Errorf("advance series: %w", err)
+	}
+
+	if last != nil {
+		b.copyChecklist(ctx, last.ID, task.ID)
+	}
+
+	return b.sendTaskCard(task, 0, fmt.Sprintf(lang.SeriesNextTask, task.ID), 0)
+}
+
+// copyChecklist переносит пункты чек-листа в следующее задание серии без отметок
+func (b *Botik) copyChecklist(ctx context.Context, fromTaskID, toTaskID int64) {
+	checklist, err := b.checklistRepo.List(ctx, fromTaskID)
+	if err != nil {
+		slog.Error("failed to get checklist", slog.Int64("task_id", fromTaskID), slog.String("error", err.Error()))
+		return
+	}
+
+	for _, item := range checklist {
+		if err := b.checklistRepo.Add(ctx, &entity.ChecklistItem{TaskID: toTaskID, Text: item.Text}); err != nil {
+			slog.Error("failed to copy checklist item", slog.Int64("task_id", toTaskID), slog.String("error", err.Error()))
+			return
+		}
+	}
+}
+
+// canManageTask проверяет, что пользователь создал задание или может менять чужие
+func (b *Botik) canManageTask(chatID, userID, createdBy int64) (bool, error) {
+	if userID == createdBy {
+		return true, nil
+	}
+
+	err := b.authorize(chatID, userID, entity.ActionEdit)
+	if errors.Is(err, ErrForbidden) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// canDeleteTask проверяет, что пользователь создал задание или может удалять чужие
+func (b *Botik) canDeleteTask(chatID, userID, createdBy int64) (bool, error) {
+	if userID == createdBy {
+		return true, nil
+	}
+
+	err := b.authorize(chatID, userID, entity.ActionDelete)
+	if errors.Is(err, ErrForbidden) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// canWorkOnTask проверяет, что пользователь исполняет задание или может его менять
+func (b *Botik) canWorkOnTask(chatID, userID int64, task *entity.Task) (bool, error) {
+	if task.IsAssignee(userID) {
+		return true, nil
+	}
+	return b.canManageTask(chatID, userID, task.CreatedBy)
+}
+
+// RepeatCmd делает задание повторяющимся: /repeat <номер> <правило>
+func (b *Botik) RepeatCmd(chatID, userID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /repeat command", slog.String("error", err.Error()))
+		}
+	}
+	fail := func(err error) {
+		slog.Error("handle /repeat command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+	}
+
+	rawID, rawRule, _ := strings.Cut(strings.TrimSpace(args), " ")
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		reply(lang.RepeatUsage)
+		return
+	}
+
+	spec, err := parseRecurrence(rawRule)
+	if err != nil {
+		reply(lang.RepeatUsage)
+		return
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, id)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if task == nil {
+		reply(lang.TaskNotFound)
+		return
+	}
+
+	allowed, err := b.canManageTask(chatID, userID, task.CreatedBy)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if !allowed {
+		reply(lang.Forbidden)
+		return
+	}
+
+	if task.SeriesID != 0 {
+		reply(lang.TaskAlreadyRepeats)
+		return
+	}
+
+	loc := b.chatLocation(chatID)
+	now := time.Now().In(loc)
+
+	// Первое вхождение: срок задания, а если его нет, ближайший подходящий день
+	start := task.DueAt.In(loc)
+	if !task.HasDeadline() {
+		today := time.Date(now.Year(), now.Month(), now.Day(), defaultDueHour, defaultDueMinute, 0, 0, loc)
+		if spec.HasTime {
+			today = time.Date(now.Year(), now.Month(), now.Day(), spec.Hour, spec.Minute, 0, 0, loc)
+		}
+		// Периоды отсчитываются от первого задания, поэтому оно ставится на ближайший подходящий день
+		first := spec.Rule
+		if !first.AfterCompletion {
+			first.Interval = 1
+		}
+		start = first.Next(today, now)
+	} else if spec.HasTime {
+		start = time.Date(start.Year(), start.Month(), start.Day(), spec.Hour, spec.Minute, 0, 0, loc)
+	}
+
+	if !start.Equal(task.DueAt) {
+		task.DueAt = start
+		err := b.taskRepo.Update(b.ctx, task)
+		switch {
+		case errors.Is(err, repository.ErrTaskNotFound):
+			reply(lang.TaskNotFound)
+			return
+		case errors.Is(err, repository.ErrVersionConflict):
+			reply(lang.TaskEditConflict)
+			return
+		case err != nil:
+			fail(err)
+			return
+		}
+	}
+
+	series := entity.NewSeries(task, spec.Rule, start)
+	if err := b.seriesRepo.Create(b.ctx, &series); err != nil {
+		fail(err)
+		return
+	}
+
+	reply(fmt.Sprintf(lang.SeriesCreated, task.ID, describeRecurrence(series.Rule, start), b.formatDue(chatID, start)))
+}
+
+// seriesListMessage текст и кнопки списка незавершённых серий чата
+func (b *Botik) seriesListMessage(chatID int64) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	list, err := b.seriesRepo.List(b.ctx, chatID)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	markup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if len(list) == 0 {
+		return lang.NoSeries, markup, nil
+	}
+
+	loc := b.chatLocation(chatID)
+	lines := []string{lang.SeriesListHeader}
+	for _, series := range list {
+		line := fmt.Sprintf(lang.SeriesLine, series.ID, series.Title, describeRecurrence(series.Rule, series.StartAt.In(loc)))
+
+		id := strconv.FormatInt(series.ID, 10)
+		toggle := tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf(lang.ButtonPauseSeries, series.ID),
+			callbackData(SeriesCallback, id, seriesPause),
+		)
+		if series.Status == entity.SeriesPaused {
+			line += " " + lang.SeriesPausedMark
+			toggle = tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf(lang.ButtonResumeSeries, series.ID),
+				callbackData(SeriesCallback, id, seriesResume),
+			)
+		}
+
+		lines = append(lines, line)
+		markup.InlineKeyboard = append(markup.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			toggle,
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf(lang.ButtonEndSeries, series.ID),
+				callbackData(SeriesCallback, id, seriesEnd),
+			),
+		))
+	}
+
+	return strings.Join(lines, "\n"), markup, nil
+}
+
+// SeriesCmd показывает повторяющиеся задания чата с кнопками паузы и завершения
+func (b *Botik) SeriesCmd(chatID int64, msgID int) {
+	text, markup, err := b.seriesListMessage(chatID)
+	if err != nil {
+		slog.Error("handle /series command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	if err := b.sendText(chatID, text, WithReply(msgID), WithKeyboard(markup)); err != nil {
+		slog.Error("handle /series command", slog.String("error", err.Error()))
+	}
+}
+
+// seriesCallback ставит серию на паузу, возобновляет или завершает её по кнопке "series:<id>:<действие>"
+func (b *Botik) seriesCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(args) < 2 {
+		return "", ErrMalformedCallback
+	}
+
+	chatID := cb.Message.Chat.ID
+
+	series, err := b.seriesRepo.GetByID(b.ctx, chatID, id)
+	if errors.Is(err, repository.ErrSeriesNotFound) {
+		return lang.SeriesNotFound, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get series: %w", err)
+	}
+
+	allowed, err := b.canManageTask(chatID, cb.From.ID, series.CreatedBy)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return lang.Forbidden, nil
+	}
+
+	var (
+		status entity.SeriesStatus
+		answer string
+	)
+	switch args[1] {
+	case seriesPause:
+		status, answer = entity.SeriesPaused, lang.SeriesPaused
+	case seriesResume:
+		status, answer = entity.SeriesActive, lang.SeriesResumed
+	case seriesEnd:
+		status, answer = entity.SeriesEnded, lang.SeriesEnded
+	default:
+		return "", ErrMalformedCallback
+	}
+
+	if series.Status == entity.SeriesEnded {
+		return lang.SeriesNotFound, nil
+	}
+
+	if err := b.seriesRepo.SetStatus(b.ctx, chatID, id, status); err != nil {
+		return "", fmt.Errorf("set series status: %w", err)
+	}
+
+	// Пока серия стояла на паузе, её задание могло быть выполнено или просрочено
+	if status == entity.SeriesActive {
+		series.Status = status
+		if err := b.advanceSeries(b.ctx, series, time.Now()); err != nil {
+			slog.Error("failed to advance task series", slog.Int64("series_id", id), slog.String("error", err.Error()))
+		}
+	}
+
+	text, markup, err := b.seriesListMessage(chatID)
+	if err != nil {
+		return answer, fmt.Errorf("render series list: %w", err)
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, text, markup)
+	if _, err := b.bot.Send(edit); err != nil {
+		slog.Error("failed to edit message", slog.String("error", err.Error()))
+	}
+	return answer, nil
+}
@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+// seriesFixture бот с чатом, заданием и серией, которая начинается с этого задания
+type seriesFixture struct {
+	b      *Botik
+	api    *fakeAPI
+	series entity.Series
+	first  *entity.Task
+}
+
+func newSeriesFixture(t *testing.T, rule entity.Recurrence, dueAt time.Time) seriesFixture {
+	t.Helper()
+
+	ctx := context.Background()
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+
+	if err := b.chatRepo.Create(ctx, entity.NewChat(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	first := &entity.Task{ChatID: 1, Title: "Вынести мусор", Status: entity.StatusOpen, AssigneeID: 8, DueAt: dueAt, CreatedBy: 7}
+	if err := b.taskRepo.Create(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+
+	series := entity.NewSeries(first, rule, dueAt)
+	if err := b.seriesRepo.Create(ctx, &series); err != nil {
+		t.Fatal(err)
+	}
+
+	return seriesFixture{b: b, api: api, series: series, first: first}
+}
+
+// lastTask возвращает последнее задание серии
+func (f seriesFixture) lastTask(t *testing.T) *entity.Task {
+	t.Helper()
+
+	series, err := f.b.seriesRepo.GetByID(context.Background(), f.series.ChatID, f.series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := f.b.taskRepo.GetByID(context.Background(), series.ChatID, series.LastTaskID)
+	if err != nil || task == nil {
+		t.Fatalf("get last series task: %v", err)
+	}
+	return task
+}
+
+func TestAdvanceSeriesByCalendar(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	f := newSeriesFixture(t, entity.Recurrence{Freq: entity.FreqDaily, Interval: 1}, start.Add(time.Hour))
+
+	clock := newFakeClock(start)
+	step := startScheduler(t, clock, f.b.advanceAllSeries)
+
+	// Срок первого задания не наступил, новое не создаётся
+	if last := f.lastTask(t); last.ID != f.first.ID {
+		t.Fatalf("series advanced to task %d before due", last.ID)
+	}
+
+	// Срок прошёл: следующее задание на завтра в то же время
+	step(2 * time.Hour)
+	second := f.lastTask(t)
+	if second.ID == f.first.ID {
+		t.Fatal("series did not advance after due date")
+	}
+	if want := start.Add(25 * time.Hour); !second.DueAt.Equal(want) {
+		t.Errorf("next task due %s, want %s", second.DueAt, want)
+	}
+	if second.SeriesID != f.series.ID || second.AssigneeID != 8 || second.Title != f.first.Title {
+		t.Errorf("next task %+v does not follow series template", second)
+	}
+	if sent := f.api.callsTo("sendMessage"); len(sent) != 1 || sent[0].Params.Get("chat_id") != "1" {
+		t.Errorf("next task announced %d times, want once in chat 1", len(sent))
+	}
+
+	// Пока новое задание не просрочено, серия стоит на месте
+	step(time.Hour)
+	if last := f.lastTask(t); last.ID != second.ID {
+		t.Fatalf("series advanced to task %d before due", last.ID)
+	}
+
+	// Бот был выключен несколько дней: пропущенные периоды не создаются
+	step(4 * 24 * time.Hour)
+	third := f.lastTask(t)
+	if want := start.Add(5*24*time.Hour + time.Hour); !third.DueAt.Equal(want) {
+		t.Errorf("task after downtime due %s, want %s", third.DueAt, want)
+	}
+}
+
+func TestAdvanceSeriesAfterCompletion(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	rule := entity.Recurrence{Freq: entity.FreqDaily, Interval: 3, AfterCompletion: true}
+	f := newSeriesFixture(t, rule, start.Add(time.Hour))
+
+	clock := newFakeClock(start)
+	step := startScheduler(t, clock, f.b.advanceAllSeries)
+
+	// Срок прошёл, но задание не выполнено
+	step(2 * time.Hour)
+	if last := f.lastTask(t); last.ID != f.first.ID {
+		t.Fatalf("series advanced to task %d before completion", last.ID)
+	}
+
+	if err := f.b.taskRepo.ChangeStatus(ctx, f.first, entity.StatusDone, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	// Следующее задание через 3 дня после выполнения во время суток начала серии
+	step(24 * time.Hour)
+	next := f.lastTask(t)
+	if next.ID == f.first.ID {
+		t.Fatal("series did not advance after completion")
+	}
+	if want := time.Date(2026, time.March, 6, 10, 0, 0, 0, time.UTC); !next.DueAt.Equal(want) {
+		t.Errorf("next task due %s, want %s", next.DueAt, want)
+	}
+}
+
+func TestAdvanceSeriesSkipsPausedSeries(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	f := newSeriesFixture(t, entity.Recurrence{Freq: entity.FreqDaily, Interval: 1}, start.Add(time.Hour))
+
+	if err := f.b.seriesRepo.SetStatus(ctx, f.series.ChatID, f.series.ID, entity.SeriesPaused); err != nil {
+		t.Fatal(err)
+	}
+
+	clock := newFakeClock(start)
+	step := startScheduler(t, clock, f.b.advanceAllSeries)
+	step(2 * time.Hour)
+
+	if last := f.lastTask(t); last.ID != f.first.ID {
+		t.Fatalf("paused series advanced to task %d", last.ID)
+	}
+}
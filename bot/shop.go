@@ -0,0 +1,395 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const (
+	ShopCallback   = "shop"
+	RedeemCallback = "redeem"
+)
+
+// Аргументы кнопок магазина и заявок на призы
+const (
+	shopPick      = "pick"   // Выбор приза, показывает подтверждение
+	shopBuy       = "buy"    // Подтверждение покупки
+	redeemDone    = "done"   // Приз выдан
+	redeemRefund  = "refund" // Вернуть сумму
+	redeemFromAll = "list"   // Кнопка из списка /orders, после обработки список перерисовывается
+)
+
+// prizeTitle название приза с ценой и остатком
+func (b *Botik) prizeTitle(prize entity.Prize) string {
+	text := fmt.Sprintf(lang.PrizeButton, prize.Title, b.formatAmount(prize.ChatID, prize.Price))
+	switch {
+	case prize.Stock == entity.UnlimitedStock:
+	case prize.Stock == 0:
+		text += " " + lang.PrizeSoldOut
+	default:
+		text += " " + fmt.Sprintf(lang.PrizeStock, prize.Stock)
+	}
+	return text
+}
+
+// shopMessage текст и кнопки каталога призов чата
+func (b *Botik) shopMessage(chatID int64) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	prizes, err := b.shopRepo.ListPrizes(b.ctx, chatID)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	markup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if len(prizes) == 0 {
+		return lang.ShopEmpty, markup, nil
+	}
+
+	for _, prize := range prizes {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				b.prizeTitle(prize),
+				callbackData(ShopCallback, shopPick, strconv.FormatInt(prize.ID, 10)),
+			),
+		))
+	}
+	return lang.ShopHeader, markup, nil
+}
+
+// ShopCmd открывает каталог призов чата
+func (b *Botik) ShopCmd(chatID int64, msgID int) {
+	text, markup, err := b.shopMessage(chatID)
+	if err != nil {
+		slog.Error("handle /shop command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	if err := b.sendText(chatID, text, WithReply(msgID), WithKeyboard(markup)); err != nil {
+		slog.Error("handle /shop command", slog.String("error", err.Error()))
+	}
+}
+
+// PrizeAddCmd добавляет приз в каталог: /prize_add <цена> [x<запас>] <название>
+func (b *Botik) PrizeAddCmd(chatID, userID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /prize_add command", slog.String("error", err.Error()))
+		}
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		reply(lang.PrizeAddUsage)
+		return
+	}
+
+	price, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || price <= 0 {
+		reply(lang.PrizeAddUsage)
+		return
+	}
+	fields = fields[1:]
+
+	stock := int64(entity.UnlimitedStock)
+	// Запас записывают латинской или русской буквой: x3, х3
+	if rest, ok := cutStockPrefix(fields[0]); ok && len(fields) > 1 {
+		stock, err = strconv.ParseInt(rest, 10, 64)
+		if err != nil || stock <= 0 {
+			reply(lang.PrizeAddUsage)
+			return
+		}
+		fields = fields[1:]
+	}
+
+	prize := &entity.Prize{
+		ChatID:    chatID,
+		Title:     strings.Join(fields, " "),
+		Price:     price,
+		Stock:     stock,
+		CreatedBy: userID,
+	}
+	if err := b.shopRepo.CreatePrize(b.ctx, prize); err != nil {
+		slog.Error("handle /prize_add command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	reply(fmt.Sprintf(lang.PrizeAdded, prize.ID, b.prizeTitle(*prize)))
+}
+
+func cutStockPrefix(field string) (string, bool) {
+	for _, prefix := range []string{"x", "х"} {
+		if rest, ok := strings.CutPrefix(strings.ToLower(field), prefix); ok {
+			_, err := strconv.ParseInt(rest, 10, 64)
+			return rest, err == nil
+		}
+	}
+	return "", false
+}
+
+// PrizeRemoveCmd убирает приз из каталога: /prize_remove <номер>
+func (b *Botik) PrizeRemoveCmd(chatID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /prize_remove command", slog.String("error", err.Error()))
+		}
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil {
+		reply(lang.PrizeRemoveUsage)
+		return
+	}
+
+	err = b.shopRepo.DisablePrize(b.ctx, chatID, id)
+	if errors.Is(err, repository.ErrPrizeNotFound) {
+		reply(lang.PrizeNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("handle /prize_remove command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	reply(lang.PrizeRemoved)
+}
+
+// shopCallback обрабатывает кнопки каталога: "shop" возвращает к каталогу, "shop:pick:<id>" просит
+// подтвердить покупку, "shop:buy:<id>" покупает приз
+func (b *Botik) shopCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	chatID := cb.Message.Chat.ID
+	msgID := cb.Message.MessageID
+
+	if len(args) == 0 {
+		return "", b.showShop(chatID, msgID)
+	}
+
+	prizeID, err := int64Arg(args, 1)
+	if err != nil {
+		return "", err
+	}
+
+	switch args[0] {
+	case shopPick:
+		prize, err := b.shopRepo.GetPrize(b.ctx, chatID, prizeID)
+		if errors.Is(err, repository.ErrPrizeNotFound) || (err == nil && !prize.Active) {
+			return lang.PrizeNotFound, b.showShop(chatID, msgID)
+		}
+		if err != nil {
+			return "", fmt.Errorf("get prize: %w", err)
+		}
+
+		balance, err := b.ledgerRepo.Balance(b.ctx, chatID, cb.From.ID)
+		if err != nil {
+			return "", fmt.Errorf("get balance: %w", err)
+		}
+
+		text := fmt.Sprintf(lang.ConfirmPurchase, prize.Title, b.formatAmount(chatID, prize.Price), b.formatAmount(chatID, balance))
+		markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBuy, callbackData(ShopCallback, shopBuy, args[1])),
+			tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBack, ShopCallback),
+		))
+		if _, err := b.bot.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, msgID, text, markup)); err != nil {
+			return "", fmt.Errorf("edit shop message: %w", err)
+		}
+		return "", nil
+	case shopBuy:
+		return b.buyPrize(cb, prizeID)
+	default:
+		return "", ErrMalformedCallback
+	}
+}
+
+// showShop перерисовывает каталог в сообщении магазина
+func (b *Botik) showShop(chatID int64, msgID int) error {
+	text, markup, err := b.shopMessage(chatID)
+	if err != nil {
+		return fmt.Errorf("render shop: %w", err)
+	}
+
+	if _, err := b.bot.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, msgID, text, markup)); err != nil {
+		return fmt.Errorf("edit shop message: %w", err)
+	}
+	return nil
+}
+
+// buyPrize списывает цену приза и отправляет администраторам заявку на выдачу
+func (b *Botik) buyPrize(cb *tgbotapi.CallbackQuery, prizeID int64) (string, error) {
+	chatID := cb.Message.Chat.ID
+
+	redemption, err := b.shopRepo.Redeem(b.ctx, chatID, prizeID, cb.From.ID)
+	switch {
+	case errors.Is(err, repository.ErrPrizeNotFound):
+		return lang.PrizeNotFound, b.showShop(chatID, cb.Message.MessageID)
+	case errors.Is(err, repository.ErrOutOfStock):
+		return lang.PrizeOutOfStock, b.showShop(chatID, cb.Message.MessageID)
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		return lang.InsufficientFunds, nil
+	case err != nil:
+		return "", fmt.Errorf("redeem prize: %w", err)
+	}
+
+	if err := b.showShop(chatID, cb.Message.MessageID); err != nil {
+		slog.Error("failed to refresh shop", slog.String("error", err.Error()))
+	}
+
+	text := fmt.Sprintf(
+		lang.RedemptionRequested,
+		newEntityUser(cb.From).Mention(),
+		redemption.Title,
+		b.formatAmount(chatID, redemption.Price),
+		redemption.ID,
+	)
+	if err := b.sendText(chatID, text, WithKeyboard(redemptionKeyboard(redemption.ID, false))); err != nil {
+		slog.Error("failed to send redemption request", slog.String("error", err.Error()))
+	}
+	return lang.PurchaseDone, nil
+}
+
+// redemptionKeyboard кнопки обработки заявки. fromList отмечает кнопки из списка /orders
+func redemptionKeyboard(id int64, fromList bool) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(redemptionRow(id, fromList, lang.ButtonFulfill, lang.ButtonRefund))
+}
+
+func redemptionRow(id int64, fromList bool, fulfill, refund string) []tgbotapi.InlineKeyboardButton {
+	args := func(op string) []string {
+		a := []string{strconv.FormatInt(id, 10), op}
+		if fromList {
+			a = append(a, redeemFromAll)
+		}
+		return a
+	}
+
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fulfill, callbackData(RedeemCallback, args(redeemDone)...)),
+		tgbotapi.NewInlineKeyboardButtonData(refund, callbackData(RedeemCallback, args(redeemRefund)...)),
+	)
+}
+
+// ordersMessage текст и кнопки списка необработанных заявок чата
+func (b *Botik) ordersMessage(chatID int64) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	pending, err := b.shopRepo.PendingRedemptions(b.ctx, chatID)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	markup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if len(pending) == 0 {
+		return lang.NoOrders, markup, nil
+	}
+
+	ids := make([]int64, 0, len(pending))
+	for _, redemption := range pending {
+		ids = append(ids, redemption.UserID)
+	}
+	users, err := b.userRepo.GetByIDs(b.ctx, ids)
+	if err != nil {
+		slog.Error("failed to get users", slog.String("error", err.Error()))
+		users = map[int64]entity.User{}
+	}
+
+	lines := []string{lang.OrdersHeader}
+	for _, redemption := range pending {
+		user, ok := users[redemption.UserID]
+		if !ok {
+			user = entity.User{ID: redemption.UserID}
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			lang.OrderLine, redemption.ID, user.DisplayName(), redemption.Title, b.formatAmount(chatID, redemption.Price),
+		))
+		markup.InlineKeyboard = append(markup.InlineKeyboard, redemptionRow(
+			redemption.ID,
+			true,
+			fmt.Sprintf(lang.ButtonFulfillOrder, redemption.ID),
+			fmt.Sprintf(lang.ButtonRefundOrder, redemption.ID),
+		))
+	}
+	return strings.Join(lines, "\n"), markup, nil
+}
+
+// OrdersCmd показывает заявки на призы, которые ждут администратора
+func (b *Botik) OrdersCmd(chatID int64, msgID int) {
+	text, markup, err := b.ordersMessage(chatID)
+	if err != nil {
+		slog.Error("handle /orders command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	if err := b.sendText(chatID, text, WithReply(msgID), WithKeyboard(markup)); err != nil {
+		slog.Error("handle /orders command", slog.String("error", err.Error()))
+	}
+}
+
+// redeemCallback выдаёт приз или возвращает сумму по кнопке "redeem:<id>:<done|refund>[:list]"
+func (b *Botik) redeemCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(args) < 2 {
+		return "", ErrMalformedCallback
+	}
+
+	chatID := cb.Message.Chat.ID
+
+	var (
+		redemption entity.Redemption
+		answer     string
+		status     string
+	)
+	switch args[1] {
+	case redeemDone:
+		redemption, err = b.shopRepo.Fulfill(b.ctx, chatID, id, cb.From.ID)
+		answer, status = lang.OrderFulfilled, lang.OrderFulfilledStatus
+	case redeemRefund:
+		redemption, err = b.shopRepo.Refund(b.ctx, chatID, id, cb.From.ID)
+		answer, status = lang.OrderRefunded, lang.OrderRefundedStatus
+	default:
+		return "", ErrMalformedCallback
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrRedemptionNotFound):
+		return lang.OrderNotFound, nil
+	case errors.Is(err, repository.ErrRedemptionResolved):
+		answer = lang.OrderAlreadyResolved
+	case err != nil:
+		return "", fmt.Errorf("resolve redemption: %w", err)
+	}
+
+	var edit tgbotapi.Chattable
+	if len(args) > 2 && args[2] == redeemFromAll {
+		text, markup, err := b.ordersMessage(chatID)
+		if err != nil {
+			return answer, fmt.Errorf("render orders: %w", err)
+		}
+		edit = tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, text, markup)
+	} else {
+		text := cb.Message.Text
+		if redemption.ID != 0 {
+			text += "\n" + fmt.Sprintf(status, newEntityUser(cb.From).Mention())
+		}
+		edit = tgbotapi.NewEditMessageTextAndMarkup(
+			chatID,
+			cb.Message.MessageID,
+			text,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}},
+		)
+	}
+
+	if _, err := b.bot.Send(edit); err != nil {
+		slog.Error("failed to edit message", slog.String("error", err.Error()))
+	}
+	return answer, nil
+}
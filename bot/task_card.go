@@ -0,0 +1,412 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const (
+	TaskCallback   = "task"
+	StatusCallback = "status"
+)
+
+var statusNames = map[entity.TaskStatus]string{
+	entity.StatusOpen:       lang.StatusOpen,
+	entity.StatusInProgress: lang.StatusInProgress,
+	entity.StatusDone:       lang.StatusDone,
+	entity.StatusAccepted:   lang.StatusAccepted,
+}
+
+// Кнопка, переводящая задание в соответствующий статус
+var statusButtons = map[entity.TaskStatus]string{
+	entity.StatusOpen:       lang.ButtonReopen,
+	entity.StatusInProgress: lang.ButtonTake,
+	entity.StatusDone:       lang.ButtonDone,
+	entity.StatusAccepted:   lang.ButtonAccept,
+}
+
+// taskCard Задание и связанные с ним данные, из которых собирается карточка
+type taskCard struct {
+	task        *entity.Task
+	checklist   entity.Checklist
+	comments    int
+	attachments int
+	assignee    string
+	creator     string
+	reward      string
+	loc         *time.Location
+
+	// Действия, доступные тому, кому показывается карточка
+	statuses  []entity.TaskStatus
+	canWork   bool // Отмечать чек-лист и прикладывать файлы
+	canEdit   bool
+	canDelete bool
+}
+
+// loadTaskCard собирает данные для карточки задания. Кнопки подбираются под права viewerID,
+// а при viewerID == 0, например для карточек от планировщика, показываются все кнопки текущего статуса
+func (b *Botik) loadTaskCard(task *entity.Task, viewerID int64) taskCard {
+	card := taskCard{
+		task:        task,
+		checklist:   b.taskChecklist(task),
+		comments:    b.commentsCount(task.ID),
+		attachments: b.attachmentsCount(task.ID),
+		assignee:    b.assigneeMention(b.ctx, task),
+		creator:     b.userMention(b.ctx, task.CreatedBy),
+		reward:      b.rewardText(task),
+		loc:         b.chatLocation(task.ChatID),
+	}
+
+	if viewerID != 0 {
+		err := b.viewerPermissions(&card, viewerID)
+		if err == nil {
+			return card
+		}
+		// Обработчики кнопок сами проверяют права, поэтому при ошибке карточка показывается со всеми кнопками
+		slog.Error("failed to get viewer permissions", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+	}
+
+	card.statuses = task.NextStatuses()
+	card.canWork, card.canEdit, card.canDelete = true, task.Status != entity.StatusAccepted, true
+	return card
+}
+
+// viewerPermissions отмечает в карточке действия, доступные пользователю
+func (b *Botik) viewerPermissions(card *taskCard, viewerID int64) error {
+	task := card.task
+
+	actor, err := b.actor(task.ChatID, viewerID)
+	if err != nil {
+		return fmt.Errorf("get actor: %w", err)
+	}
+
+	card.statuses = nil
+	for _, status := range task.NextStatuses() {
+		if task.CanTransition(status, actor, b.policy) == nil {
+			card.statuses = append(card.statuses, status)
+		}
+	}
+
+	if card.canWork, err = b.canWorkOnTask(task.ChatID, viewerID, task); err != nil {
+		return err
+	}
+	if card.canEdit, err = b.canManageTask(task.ChatID, viewerID, task.CreatedBy); err != nil {
+		return err
+	}
+	card.canEdit = card.canEdit && task.Status != entity.StatusAccepted
+	if card.canDelete, err = b.canDeleteTask(task.ChatID, viewerID, task.CreatedBy); err != nil {
+		return err
+	}
+	return nil
+}
+
+// text текст карточки в разметке HTML
+func (c taskCard) text() string {
+	task := c.task
+	text := fmt.Sprintf(
+		lang.DetailedTask,
+		task.ID,
+		html.EscapeString(task.Title),
+		html.EscapeString(task.Description),
+		html.EscapeString(c.reward),
+		html.EscapeString(c.assignee),
+		html.EscapeString(c.creator),
+		task.CreatedAt.In(c.loc).Format(dueLayout),
+	) + fmt.Sprintf(lang.TaskStatusLine, statusNames[task.Status])
+
+	if task.HasDeadline() {
+		text += "\n" + fmt.Sprintf(lang.TaskDueLine, task.DueAt.In(c.loc).Format(dueLayout))
+	}
+	if task.SeriesID != 0 {
+		text += "\n" + fmt.Sprintf(lang.TaskSeriesLine, task.SeriesID)
+	}
+	if c.attachments > 0 {
+		text += "\n" + fmt.Sprintf(lang.TaskAttachmentsLine, c.attachments)
+	}
+	if task.NeedsReassignment {
+		text += "\n" + lang.TaskNeedsReassignment
+	}
+	if len(c.checklist) > 0 {
+		text += "\n\n" + checklistText(task, c.checklist)
+	}
+	return text
+}
+
+// keyboard кнопки действий с заданием, доступных тому, кому показывается карточка
+func (c taskCard) keyboard() tgbotapi.InlineKeyboardMarkup {
+	task := c.task
+	id := strconv.FormatInt(task.ID, 10)
+
+	markup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if c.canWork {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, checklistButtons(task, c.checklist)...)
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, status := range c.statuses {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(statusButtons[status], callbackData(StatusCallback, id, string(status))))
+	}
+	if len(row) > 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+	}
+
+	row = nil
+	if c.canEdit {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(lang.ButtonEdit, callbackData(EditCallback, id)))
+	}
+	if c.canDelete {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(lang.ButtonDelete, callbackData(DeleteCallback, id)))
+	}
+	if len(row) > 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+	}
+
+	row = []tgbotapi.InlineKeyboardButton{commentsButton(task.ID, c.comments)}
+	row = append(row, attachmentButtons(task.ID, c.attachments, c.canWork)...)
+	markup.InlineKeyboard = append(markup.InlineKeyboard, row, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBackToList, callbackData(ListCallback, "0")),
+	))
+	return markup
+}
+
+// sendTaskCard отправляет карточку задания новым сообщением с кнопками для viewerID
+func (b *Botik) sendTaskCard(task *entity.Task, viewerID int64, header string, msgID int) error {
+	card := b.loadTaskCard(task, viewerID)
+	text := card.text()
+	if header != "" {
+		text = header + "\n\n" + text
+	}
+
+	sent, err := b.sendMessage(
+		task.ChatID, text, WithReply(msgID), WithKeyboard(card.keyboard()), WithParseMode(tgbotapi.ModeHTML),
+	)
+	if err != nil {
+		return err
+	}
+
+	b.linkTaskMessage(task.ID, task.ChatID, sent.MessageID)
+	return nil
+}
+
+// refreshTaskCard перерисовывает карточку задания в уже отправленном сообщении с кнопками для viewerID
+func (b *Botik) refreshTaskCard(task *entity.Task, viewerID int64, msgID int) error {
+	card := b.loadTaskCard(task, viewerID)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(task.ChatID, msgID, card.text(), card.keyboard())
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := b.bot.Send(edit); err != nil {
+		return fmt.Errorf("editing task card: %w", err)
+	}
+
+	// Карточка могла открыться в сообщении, которое раньше относилось к другому заданию
+	b.linkTaskMessage(task.ID, task.ChatID, msgID)
+	return nil
+}
+
+// TasksCmd показывает первую страницу списка заданий чата. Аргументы: мои, активные, срок и слова для поиска
+func (b *Botik) TasksCmd(chatID, userID int64, msgID int, args string) {
+	list := repository.NewTaskList(chatID, parseTaskFilter(chatID, userID, args))
+
+	text, markup, err := b.createTaskListMessage(&list)
+	if err != nil {
+		slog.Error("handle /tasks command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	sent, err := b.sendMessage(chatID, text, WithReply(msgID), WithKeyboard(markup))
+	if err != nil {
+		slog.Error("handle /tasks command", slog.String("error", err.Error()))
+		return
+	}
+
+	// Фильтр и курсоры хранятся по сообщению, кнопки листания передают только направление
+	list.MessageID = sent.MessageID
+	if err := b.taskListRepo.Save(b.ctx, list); err != nil {
+		slog.Error("handle /tasks command", slog.String("error", err.Error()))
+	}
+}
+
+// listCallback листает список заданий в том же сообщении по кнопке "list:prev" или "list:next".
+// Другой аргумент открывает первую страницу с фильтром, с которым список был показан в этом сообщении
+func (b *Botik) listCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	chatID, msgID := cb.Message.Chat.ID, cb.Message.MessageID
+
+	list, err := b.taskListRepo.Get(b.ctx, chatID, msgID)
+	switch {
+	case errors.Is(err, repository.ErrTaskListNotFound):
+		list = repository.NewTaskList(chatID, repository.TaskFilter{ChatID: chatID})
+		list.MessageID = msgID
+	case err != nil:
+		return "", fmt.Errorf("get task list: %w", err)
+	}
+
+	direction := ""
+	if len(args) > 0 {
+		direction = args[0]
+	}
+	switch {
+	case direction == listPrev && len(list.Cursors) > 1:
+		list.Cursors = list.Cursors[:len(list.Cursors)-1]
+	case direction == listNext && list.Next != "":
+		list.Cursors = append(list.Cursors, list.Next)
+	case direction != listPrev && direction != listNext:
+		list.Cursors = []string{""}
+	}
+
+	text, markup, err := b.createTaskListMessage(&list)
+	if err != nil {
+		return "", fmt.Errorf("render task list: %w", err)
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, msgID, text, markup)
+	if _, err := b.bot.Send(edit); err != nil {
+		return "", fmt.Errorf("edit task list: %w", err)
+	}
+
+	if err := b.taskListRepo.Save(b.ctx, list); err != nil {
+		return "", fmt.Errorf("save task list: %w", err)
+	}
+	return "", nil
+}
+
+// TaskCmd показывает карточку задания по номеру из аргумента команды
+func (b *Botik) TaskCmd(chatID, userID int64, msgID int, args string) {
+	id, err := strconv.ParseInt(args, 10, 64)
+	if err != nil {
+		if err := b.sendText(chatID, lang.TaskUsage, WithReply(msgID)); err != nil {
+			slog.Error("handle /task command", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, id)
+	if err != nil {
+		slog.Error("handle /task command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	if task == nil {
+		err = b.sendText(chatID, lang.TaskNotFound, WithReply(msgID))
+	} else {
+		err = b.sendTaskCard(task, userID, "", msgID)
+	}
+	if err != nil {
+		slog.Error("handle /task command", slog.String("error", err.Error()))
+	}
+}
+
+// showTaskCallback открывает карточку задания в сообщении с нажатой кнопкой
+func (b *Botik) showTaskCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, cb.Message.Chat.ID, id)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	return "", b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+}
+
+// changeStatus переводит задание в статус to от имени участника и выполняет то, что следует за сменой статуса:
+// награду, запрос подтверждения, уведомление автора и продолжение серии.
+// Возвращает ошибки CanTransition или repository.ErrStatusConflict, если статус уже сменили
+func (b *Botik) changeStatus(task *entity.Task, to entity.TaskStatus, user entity.User, msgID int) error {
+	actor, err := b.actor(task.ChatID, user.ID)
+	if err != nil {
+		return fmt.Errorf("get actor: %w", err)
+	}
+	if err := task.CanTransition(to, actor, b.policy); err != nil {
+		return err
+	}
+
+	err = b.taskRepo.ChangeStatus(b.ctx, task, to, user.ID)
+	if errors.Is(err, repository.ErrStatusConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("change task status: %w", err)
+	}
+
+	if to == entity.StatusAccepted {
+		b.announceReward(task)
+	}
+	if to == entity.StatusDone {
+		b.notifyDone(task, user)
+	}
+	if to == entity.StatusDone && task.IsAssignee(user.ID) {
+		// Подтверждение необязательно, поэтому его запрос не мешает смене статуса
+		err := b.askAttachment(task, user.ID, msgID, true)
+		if err != nil && !errors.Is(err, errConversationBusy) {
+			slog.Error("failed to ask for proof", slog.Int64("task_id", task.ID), slog.String("error", err.Error()))
+		}
+	}
+	if task.SeriesID != 0 && task.IsCompleted() {
+		b.continueSeries(task)
+	}
+	return nil
+}
+
+// notifyDone пишет автору задания в личные сообщения, что задание выполнено
+func (b *Botik) notifyDone(task *entity.Task, user entity.User) {
+	// В личном чате автор и так видит карточку
+	if task.CreatedBy == 0 || task.CreatedBy == user.ID || task.CreatedBy == task.ChatID {
+		return
+	}
+	b.enqueue(tgbotapi.NewMessage(task.CreatedBy, fmt.Sprintf(lang.TaskDoneNotification, user.DisplayName(), task.ID, task.Title)))
+}
+
+// changeStatusCallback переводит задание в статус из payload "status:<id>:<статус>"
+func (b *Botik) changeStatusCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(args) < 2 {
+		return "", ErrMalformedCallback
+	}
+	to := entity.TaskStatus(args[1])
+
+	ctx := b.ctx
+
+	task, err := b.taskRepo.GetByID(ctx, cb.Message.Chat.ID, id)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	err = b.changeStatus(task, to, newEntityUser(cb.From), cb.Message.MessageID)
+	switch {
+	case errors.Is(err, entity.ErrTransitionNotAllowed):
+		return lang.TransitionNotAllowed, b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+	case errors.Is(err, entity.ErrActorNotAllowed):
+		return lang.Forbidden, nil
+	case errors.Is(err, repository.ErrStatusConflict):
+		task, err = b.taskRepo.GetByID(ctx, cb.Message.Chat.ID, id)
+		if err != nil || task == nil {
+			return lang.TaskChanged, err
+		}
+		return lang.TaskChanged, b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+	case err != nil:
+		return "", err
+	}
+
+	return statusNames[to], b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+}
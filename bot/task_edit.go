@@ -0,0 +1,413 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+const (
+	EditCallback   = "edit"
+	DeleteCallback = "delete"
+)
+
+// deleteConfirm Аргумент кнопки, подтверждающей удаление
+const deleteConfirm = "ok"
+
+// Поля задания, которые можно изменить
+const (
+	fieldTitle       = "title"
+	fieldDescription = "description"
+	fieldReward      = "reward"
+	fieldDue         = "due"
+	fieldAssignee    = "assignee"
+)
+
+// Ограничения длины текстовых полей задания
+const (
+	maxTitleLength       = 128
+	maxDescriptionLength = 1000
+)
+
+// noDueAnswers ответы, которыми при изменении срока его можно убрать
+var noDueAnswers = map[string]bool{"нет": true, "-": true, "без срока": true}
+
+// editCallback обрабатывает кнопки изменения задания: "edit:<id>" открывает меню полей,
+// "edit:<id>:<поле>" запрашивает новое значение, исполнитель выбирается кнопкой "edit:<id>:assignee"
+func (b *Botik) editCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	chatID := cb.Message.Chat.ID
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, id)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	allowed, err := b.canManageTask(chatID, cb.From.ID, task.CreatedBy)
+	if err != nil {
+		return "", fmt.Errorf("check permissions: %w", err)
+	}
+	if !allowed {
+		return lang.Forbidden, nil
+	}
+	if task.Status == entity.StatusAccepted {
+		return lang.TaskAcceptedLocked, b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+	}
+
+	if len(args) == 1 {
+		return "", b.showEditMenu(cb, task)
+	}
+
+	switch args[1] {
+	case fieldAssignee:
+		return b.editAssignee(cb, task, args[2:])
+	case fieldTitle, fieldDescription, fieldReward, fieldDue:
+		return b.askEditValue(cb, task, args[1])
+	default:
+		return "", ErrMalformedCallback
+	}
+}
+
+// showEditMenu заменяет карточку задания меню выбора поля
+func (b *Botik) showEditMenu(cb *tgbotapi.CallbackQuery, task *entity.Task) error {
+	id := strconv.FormatInt(task.ID, 10)
+	button := func(text, field string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(text, callbackData(EditCallback, id, field))
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button(lang.ButtonEditTitle, fieldTitle), button(lang.ButtonEditDesc, fieldDescription)),
+		tgbotapi.NewInlineKeyboardRow(button(lang.ButtonEditReward, fieldReward), button(lang.ButtonEditDue, fieldDue)),
+		tgbotapi.NewInlineKeyboardRow(button(lang.ButtonEditAssignee, fieldAssignee)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBack, callbackData(TaskCallback, id))),
+	)
+
+	text := fmt.Sprintf(lang.EditMenu, task.ID, task.Title)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID, text, markup)
+	if _, err := b.bot.Send(edit); err != nil {
+		return fmt.Errorf("edit task card: %w", err)
+	}
+	return nil
+}
+
+// editAssignee показывает участников чата, а по кнопке "edit:<id>:assignee:<userID>:<версия>" назначает исполнителя.
+// Нулевой userID снимает исполнителя
+func (b *Botik) editAssignee(cb *tgbotapi.CallbackQuery, task *entity.Task, args []string) (string, error) {
+	chatID := cb.Message.Chat.ID
+	id := strconv.FormatInt(task.ID, 10)
+
+	if len(args) == 0 {
+		members, err := b.chatMembers(b.ctx, chatID)
+		if err != nil {
+			return "", err
+		}
+
+		version := strconv.FormatInt(task.Version, 10)
+		rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(members)+2)
+		for _, member := range members {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				member.DisplayName(),
+				callbackData(EditCallback, id, fieldAssignee, strconv.FormatInt(member.ID, 10), version),
+			)))
+		}
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				lang.ButtonNoAssignee, callbackData(EditCallback, id, fieldAssignee, "0", version),
+			)),
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBack, callbackData(EditCallback, id))),
+		)
+
+		text := fmt.Sprintf(lang.AskNewAssignee, task.ID, task.Title)
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+		if _, err := b.bot.Send(edit); err != nil {
+			return "", fmt.Errorf("edit task card: %w", err)
+		}
+		return "", nil
+	}
+
+	assigneeID, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+	version, err := int64Arg(args, 1)
+	if err != nil {
+		return "", err
+	}
+
+	if assigneeID != 0 {
+		isMember, err := b.chatRepo.IsMember(b.ctx, chatID, assigneeID)
+		if err != nil {
+			return "", fmt.Errorf("check chat member: %w", err)
+		}
+		if !isMember {
+			return lang.NotChatMember, nil
+		}
+	}
+
+	task.AssigneeID, task.Assignee, task.NeedsReassignment = assigneeID, "", false
+	task.Version = version
+
+	switch err := b.taskRepo.Update(b.ctx, task); {
+	case errors.Is(err, repository.ErrTaskNotFound):
+		return lang.TaskNotFound, nil
+	case errors.Is(err, repository.ErrVersionConflict):
+		return lang.TaskEditConflict, b.reloadTaskCard(chatID, task.ID, cb.From.ID, cb.Message.MessageID)
+	case err != nil:
+		return "", fmt.Errorf("update task: %w", err)
+	}
+
+	return fmt.Sprintf(lang.TaskUpdated, task.ID), b.refreshTaskCard(task, cb.From.ID, cb.Message.MessageID)
+}
+
+// askEditValue начинает диалог ввода нового значения текстового поля задания
+func (b *Botik) askEditValue(cb *tgbotapi.CallbackQuery, task *entity.Task, field string) (string, error) {
+	chatID := cb.Message.Chat.ID
+
+	conv, err := b.convRepo.Get(b.ctx, chatID, cb.From.ID)
+	switch {
+	case err == nil && conv.State != stateEditingField:
+		return lang.ConversationInProgress, nil
+	case err != nil && !errors.Is(err, repository.ErrConversationNotFound):
+		return "", fmt.Errorf("get conversation: %w", err)
+	}
+
+	var question string
+	switch field {
+	case fieldTitle:
+		question = fmt.Sprintf(lang.AskNewTitle, task.Title, task.ID, maxTitleLength)
+	case fieldDescription:
+		question = fmt.Sprintf(lang.AskNewDesc, task.Description, task.ID, maxDescriptionLength)
+	case fieldReward:
+		question = fmt.Sprintf(lang.AskNewReward, b.rewardText(task), task.ID, b.chatCurrency(chatID))
+	case fieldDue:
+		current := lang.NoDueDate
+		if task.HasDeadline() {
+			current = task.DueAt.In(b.chatLocation(chatID)).Format(dueLayout)
+		}
+		question = fmt.Sprintf(lang.AskNewDue, current, task.ID)
+	}
+
+	conv = entity.NewConversation(chatID, cb.From.ID, stateEditingField)
+	conv.Data[keyTaskID] = strconv.FormatInt(task.ID, 10)
+	conv.Data[keyField] = field
+	conv.Data[keyVersion] = strconv.FormatInt(task.Version, 10)
+	conv.Data[keyCardMsgID] = strconv.Itoa(cb.Message.MessageID)
+	if err := b.convRepo.Save(b.ctx, conv); err != nil {
+		return "", fmt.Errorf("save conversation: %w", err)
+	}
+
+	if err := b.sendText(chatID, question, WithReply(cb.Message.MessageID), WithKeyboard(cancelKeyboard())); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// finishEdit проверяет и сохраняет новое значение поля, затем обновляет карточку задания, с которой начато изменение.
+// При неверном значении диалог продолжается, чтобы пользователь мог ввести его заново
+func (b *Botik) finishEdit(ctx context.Context, conv entity.Conversation, msg *tgbotapi.Message, value string) {
+	fail := func(err error) {
+		slog.Error("failed to edit task", slog.String("error", err.Error()))
+		b.sendFailedStub(conv.ChatID, msg.MessageID)
+	}
+	reply := func(text string) {
+		if err := b.sendText(conv.ChatID, text, WithReply(msg.MessageID)); err != nil {
+			slog.Error(err.Error())
+		}
+	}
+	finish := func(text string) {
+		if err := b.convRepo.Delete(ctx, conv.ChatID, conv.UserID); err != nil {
+			slog.Error("failed to delete conversation", slog.String("error", err.Error()))
+		}
+		reply(text)
+	}
+
+	taskID, err := strconv.ParseInt(conv.Data[keyTaskID], 10, 64)
+	if err != nil {
+		fail(fmt.Errorf("malformed edit conversation: %w", err))
+		return
+	}
+	cardMsgID, _ := strconv.Atoi(conv.Data[keyCardMsgID])
+
+	task, err := b.taskRepo.GetByID(ctx, conv.ChatID, taskID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if task == nil {
+		finish(lang.TaskNotFound)
+		return
+	}
+
+	// Права и статус могли измениться, пока пользователь вводил значение
+	allowed, err := b.canManageTask(conv.ChatID, conv.UserID, task.CreatedBy)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if !allowed {
+		finish(lang.Forbidden)
+		return
+	}
+	if task.Status == entity.StatusAccepted {
+		finish(lang.TaskAcceptedLocked)
+		return
+	}
+
+	result := fmt.Sprintf(lang.TaskUpdated, task.ID)
+	switch conv.Data[keyField] {
+	case fieldTitle:
+		if utf8.RuneCountInString(value) > maxTitleLength {
+			reply(fmt.Sprintf(lang.TitleTooLong, maxTitleLength))
+			return
+		}
+		task.Title = value
+	case fieldDescription:
+		if utf8.RuneCountInString(value) > maxDescriptionLength {
+			reply(fmt.Sprintf(lang.DescTooLong, maxDescriptionLength))
+			return
+		}
+		task.Description = value
+	case fieldReward:
+		amount, err := parseAmount(value)
+		if err != nil || amount < 0 {
+			reply(lang.InvalidReward)
+			return
+		}
+		task.Reward, task.RewardAmount = "", amount
+	case fieldDue:
+		if noDueAnswers[strings.ToLower(value)] {
+			task.DueAt = time.Time{}
+			result = fmt.Sprintf(lang.TaskDueRemoved, task.ID)
+			break
+		}
+
+		now := time.Now().In(b.chatLocation(conv.ChatID))
+		due, err := parseDueDate(value, now)
+		if err != nil {
+			reply(lang.InvalidDueDate)
+			return
+		}
+		if !due.Time.After(now) {
+			reply(lang.DueDateInPast)
+			return
+		}
+		task.DueAt = due.Time
+		// Срок, понятый не дословно, показываем с днём недели, чтобы ошибку было легко заметить
+		result = fmt.Sprintf(lang.TaskDueUpdated, task.ID, lang.WeekdaysShort[due.Time.Weekday()], due.Time.Format(dueLayout))
+	default:
+		slog.Warn("unknown edited field", slog.String("field", conv.Data[keyField]))
+		finish(lang.FailedStub)
+		return
+	}
+
+	// Диалоги, начатые до появления версий, сравниваются с только что загруженным заданием
+	if version, err := strconv.ParseInt(conv.Data[keyVersion], 10, 64); err == nil {
+		task.Version = version
+	}
+
+	switch err := b.taskRepo.Update(ctx, task); {
+	case errors.Is(err, repository.ErrTaskNotFound):
+		finish(lang.TaskNotFound)
+		return
+	case errors.Is(err, repository.ErrVersionConflict):
+		finish(lang.TaskEditConflict)
+		if err := b.reloadTaskCard(conv.ChatID, task.ID, conv.UserID, cardMsgID); err != nil {
+			slog.Error("failed to refresh task card", slog.String("error", err.Error()))
+		}
+		return
+	case err != nil:
+		fail(err)
+		return
+	}
+
+	finish(result)
+	if cardMsgID != 0 {
+		if err := b.refreshTaskCard(task, conv.UserID, cardMsgID); err != nil {
+			slog.Error("failed to refresh task card", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// reloadTaskCard заново читает задание и показывает его актуальную карточку в сообщении msgID
+func (b *Botik) reloadTaskCard(chatID, taskID, viewerID int64, msgID int) error {
+	if msgID == 0 {
+		return nil
+	}
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, taskID)
+	if err != nil {
+		return fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return nil
+	}
+	return b.refreshTaskCard(task, viewerID, msgID)
+}
+
+// deleteCallback по кнопке "delete:<id>" спрашивает подтверждение, по "delete:<id>:ok" удаляет задание
+func (b *Botik) deleteCallback(cb *tgbotapi.CallbackQuery, args []string) (string, error) {
+	id, err := int64Arg(args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	chatID := cb.Message.Chat.ID
+
+	task, err := b.taskRepo.GetByID(b.ctx, chatID, id)
+	if err != nil {
+		return "", fmt.Errorf("get task: %w", err)
+	}
+	if task == nil {
+		return lang.TaskNotFound, nil
+	}
+
+	allowed, err := b.canDeleteTask(chatID, cb.From.ID, task.CreatedBy)
+	if err != nil {
+		return "", fmt.Errorf("check permissions: %w", err)
+	}
+	if !allowed {
+		return lang.Forbidden, nil
+	}
+
+	if len(args) < 2 || args[1] != deleteConfirm {
+		markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.ButtonConfirmDelete, callbackData(DeleteCallback, args[0], deleteConfirm)),
+			tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBack, callbackData(TaskCallback, args[0])),
+		))
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, fmt.Sprintf(lang.ConfirmDelete, task.ID, task.Title), markup)
+		if _, err := b.bot.Send(edit); err != nil {
+			return "", fmt.Errorf("edit task card: %w", err)
+		}
+		return "", nil
+	}
+
+	if err := b.taskRepo.Delete(b.ctx, chatID, task.ID); err != nil {
+		return "", fmt.Errorf("delete task: %w", err)
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(lang.ButtonBackToList, callbackData(ListCallback, "0")),
+	))
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, cb.Message.MessageID, fmt.Sprintf(lang.TaskDeleted, task.ID), markup)
+	if _, err := b.bot.Send(edit); err != nil {
+		slog.Error("failed to edit message", slog.String("error", err.Error()))
+	}
+	return lang.Deleted, nil
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+// newEditedTask создаёт чат с автором задания и само задание
+func newEditedTask(t *testing.T, b *Botik) *entity.Task {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := b.chatRepo.Create(ctx, entity.NewChat(testChatID)); err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []int64{testCreator, testAssignee} {
+		if err := b.chatRepo.SaveMember(ctx, entity.NewChatMember(testChatID, userID, entity.RoleMember)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	task := &entity.Task{ChatID: testChatID, Title: "Полить цветы", CreatedBy: testCreator}
+	if err := b.taskRepo.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// pressEdit нажимает кнопку изменения задания в карточке cardMsgID от имени автора
+func pressEdit(t *testing.T, b *Botik, cardMsgID int, args ...string) string {
+	t.Helper()
+
+	cb := &tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: testCreator, FirstName: "Петя"},
+		Message: &tgbotapi.Message{MessageID: cardMsgID, Chat: &tgbotapi.Chat{ID: testChatID}},
+	}
+	answer, err := b.editCallback(cb, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return answer
+}
+
+// changeConcurrently изменяет задание в обход бота, как если бы его успел изменить другой участник
+func changeConcurrently(t *testing.T, b *Botik, task *entity.Task, title string) {
+	t.Helper()
+
+	other, err := b.taskRepo.GetByID(context.Background(), task.ChatID, task.ID)
+	if err != nil || other == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	other.Title = title
+	if err := b.taskRepo.Update(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEditConversationConflict(t *testing.T) {
+	const cardMsgID = 42
+
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+	task := newEditedTask(t, b)
+	ctx := context.Background()
+
+	pressEdit(t, b, cardMsgID, strconv.FormatInt(task.ID, 10), fieldTitle)
+
+	conv, err := b.convRepo.Get(ctx, testChatID, testCreator)
+	if err != nil || conv.State != stateEditingField {
+		t.Fatalf("edit conversation %+v, %v", conv, err)
+	}
+
+	// Пока автор вводит название, задание меняет другой участник
+	changeConcurrently(t, b, task, "Полить цветы на балконе")
+
+	msg := &tgbotapi.Message{MessageID: 43, Chat: &tgbotapi.Chat{ID: testChatID}, From: &tgbotapi.User{ID: testCreator}}
+	b.finishEdit(ctx, conv, msg, "Выбросить цветы")
+
+	got, err := b.taskRepo.GetByID(ctx, testChatID, task.ID)
+	if err != nil || got == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.Title != "Полить цветы на балконе" {
+		t.Errorf("title %q, the concurrent change was overwritten", got.Title)
+	}
+
+	if text := lastCall(t, api, "sendMessage").Params.Get("text"); text != lang.TaskEditConflict {
+		t.Errorf("reply %q, want %q", text, lang.TaskEditConflict)
+	}
+	if _, err := b.convRepo.Get(ctx, testChatID, testCreator); !errors.Is(err, repository.ErrConversationNotFound) {
+		t.Errorf("conversation after conflict: %v, want it finished", err)
+	}
+
+	// Карточка показывает актуальное задание
+	edit := lastCall(t, api, "editMessageText")
+	if edit.Params.Get("message_id") != strconv.Itoa(cardMsgID) || !strings.Contains(edit.Params.Get("text"), got.Title) {
+		t.Errorf("card refreshed as %v", edit.Params)
+	}
+}
+
+func TestEditAssigneeConflict(t *testing.T) {
+	const cardMsgID = 42
+
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+	task := newEditedTask(t, b)
+	ctx := context.Background()
+
+	// Кнопка выбора исполнителя несёт версию задания на момент показа списка
+	version := strconv.FormatInt(task.Version, 10)
+	changeConcurrently(t, b, task, "Полить цветы на балконе")
+
+	answer := pressEdit(t, b, cardMsgID, strconv.FormatInt(task.ID, 10), fieldAssignee, strconv.Itoa(testAssignee), version)
+	if answer != lang.TaskEditConflict {
+		t.Errorf("answer %q, want %q", answer, lang.TaskEditConflict)
+	}
+
+	got, err := b.taskRepo.GetByID(ctx, testChatID, task.ID)
+	if err != nil || got == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.AssigneeID != 0 || got.Title != "Полить цветы на балконе" {
+		t.Errorf("task %+v changed by a stale button", got)
+	}
+
+	// Без конфликта исполнитель назначается
+	answer = pressEdit(t, b, cardMsgID, strconv.FormatInt(task.ID, 10), fieldAssignee, strconv.Itoa(testAssignee), strconv.FormatInt(got.Version, 10))
+	if answer == lang.TaskEditConflict {
+		t.Fatalf("answer %q for the current version", answer)
+	}
+	if got, _ := b.taskRepo.GetByID(ctx, testChatID, task.ID); got == nil || got.AssigneeID != testAssignee || got.Version != task.Version+2 {
+		t.Errorf("task after assignment %+v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/qrave1/task-track/entity"
+	"github.com/qrave1/task-track/lang"
+)
+
+// lastCall последний запрос к методу Bot API
+func lastCall(t *testing.T, api *fakeAPI, method string) apiCall {
+	t.Helper()
+
+	calls := api.callsTo(method)
+	if len(calls) == 0 {
+		t.Fatalf("no %s calls", method)
+	}
+	return calls[len(calls)-1]
+}
+
+// pressList нажимает кнопку листания в сообщении со списком
+func pressList(t *testing.T, b *Botik, msgID int, direction string) {
+	t.Helper()
+
+	cb := &tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: testCreator, FirstName: "Петя"},
+		Message: &tgbotapi.Message{MessageID: msgID, Chat: &tgbotapi.Chat{ID: testChatID}},
+	}
+	if _, err := b.listCallback(cb, []string{direction}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTasksListPagesWithFilter(t *testing.T) {
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+
+	// Семь заданий исполнителя и одно чужое: фильтр «мои» даёт две страницы
+	for i := 1; i <= 8; i++ {
+		task := &entity.Task{ChatID: testChatID, Title: fmt.Sprintf("Задание %d", i), AssigneeID: testAssignee, CreatedBy: testCreator}
+		if i == 8 {
+			task.AssigneeID = testAdmin
+		}
+		if err := b.taskRepo.Create(context.Background(), task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b.TasksCmd(testChatID, testAssignee, 1, "мои")
+
+	sent := lastCall(t, api, "sendMessage")
+	text, markup := sent.Params.Get("text"), sent.Params.Get("reply_markup")
+	if !strings.HasPrefix(text, fmt.Sprintf(lang.TaskListFilteredHeader, 1)) || strings.Contains(text, "Задание 8") {
+		t.Errorf("first page %q", text)
+	}
+	if !strings.Contains(markup, callbackData(ListCallback, listNext)) || strings.Contains(markup, callbackData(ListCallback, listPrev)) {
+		t.Errorf("first page buttons %s", markup)
+	}
+
+	// Сообщение со списком получило следующий ID поддельного API
+	msgID := len(api.callsTo("sendMessage"))
+
+	pressList(t, b, msgID, listNext)
+	edit := lastCall(t, api, "editMessageText")
+	text, markup = edit.Params.Get("text"), edit.Params.Get("reply_markup")
+	for _, title := range []string{"Задание 1", "Задание 2"} {
+		if !strings.Contains(text, title) {
+			t.Errorf("second page %q has no %q", text, title)
+		}
+	}
+	if strings.Contains(text, "Задание 3") || strings.Contains(text, "Задание 8") {
+		t.Errorf("second page %q repeats the first page or ignores the filter", text)
+	}
+	if strings.Contains(markup, callbackData(ListCallback, listNext)) || !strings.Contains(markup, callbackData(ListCallback, listPrev)) {
+		t.Errorf("last page buttons %s", markup)
+	}
+
+	// Следующей страницы нет, кнопка от старого сообщения список не сдвигает
+	pressList(t, b, msgID, listNext)
+	if text := lastCall(t, api, "editMessageText").Params.Get("text"); !strings.Contains(text, "Задание 1") {
+		t.Errorf("page after the last one %q", text)
+	}
+
+	pressList(t, b, msgID, listPrev)
+	if text := lastCall(t, api, "editMessageText").Params.Get("text"); !strings.Contains(text, "Задание 7") || strings.Contains(text, "Задание 8") {
+		t.Errorf("previous page %q", text)
+	}
+}
+
+func TestTasksSearch(t *testing.T) {
+	api := newFakeAPI(t)
+	b := newTestBotik(t, newTestConfig(api), newTestDB(t))
+
+	var ids []int64
+	for _, title := range []string{"Купить молоко", "Помыть машину"} {
+		task := &entity.Task{ChatID: testChatID, Title: title, CreatedBy: testCreator}
+		if err := b.taskRepo.Create(context.Background(), task); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	b.TasksCmd(testChatID, testCreator, 1, "молоко")
+	markup := lastCall(t, api, "sendMessage").Params.Get("reply_markup")
+	if !strings.Contains(markup, callbackData(TaskCallback, strconv.FormatInt(ids[0], 10))) ||
+		strings.Contains(markup, callbackData(TaskCallback, strconv.FormatInt(ids[1], 10))) {
+		t.Errorf("search buttons %s", markup)
+	}
+
+	b.TasksCmd(testChatID, testCreator, 1, "хлеб")
+	if text := lastCall(t, api, "sendMessage").Params.Get("text"); text != lang.NoTasksFound {
+		t.Errorf("empty search %q, want %q", text, lang.NoTasksFound)
+	}
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qrave1/task-track/lang"
+	"github.com/qrave1/task-track/repository"
+)
+
+var utcOffsetRe = regexp.MustCompile(`^(?:utc|gmt)?([+-])(\d{1,2})(?::(\d{2}))?$`)
+
+// loadTimeZone загружает часовой пояс по имени IANA ("Europe/Moscow") или смещению от UTC ("UTC+3", "+05:30")
+func loadTimeZone(name string) (*time.Location, error) {
+	m := utcOffsetRe.FindStringSubmatch(strings.ToLower(name))
+	if m == nil {
+		return time.LoadLocation(name)
+	}
+
+	hours, _ := strconv.Atoi(m[2])
+	minutes := 0
+	if m[3] != "" {
+		minutes, _ = strconv.Atoi(m[3])
+	}
+	if hours > 14 || minutes > 59 {
+		return nil, fmt.Errorf("utc offset out of range: %s", name)
+	}
+
+	offset := hours*3600 + minutes*60
+	if m[1] == "-" {
+		offset = -offset
+	}
+	return time.FixedZone(strings.ToUpper(name), offset), nil
+}
+
+// chatLocation возвращает часовой пояс чата, а если он не задан, пояс из настроек бота
+func (b *Botik) chatLocation(chatID int64) *time.Location {
+	chat, err := b.chatRepo.GetByID(b.ctx, chatID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrChatNotFound) {
+			slog.Error("failed to get chat", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		}
+		return b.location
+	}
+
+	if chat.TimeZone == "" {
+		return b.location
+	}
+
+	loc, err := loadTimeZone(chat.TimeZone)
+	if err != nil {
+		slog.Error("invalid chat time zone", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		return b.location
+	}
+	return loc
+}
+
+// TimezoneCmd показывает или меняет часовой пояс чата: /timezone Europe/Moscow
+func (b *Botik) TimezoneCmd(chatID int64, msgID int, args string) {
+	reply := func(text string) {
+		if err := b.sendText(chatID, text, WithReply(msgID)); err != nil {
+			slog.Error("handle /timezone command", slog.String("error", err.Error()))
+		}
+	}
+
+	name := strings.TrimSpace(args)
+	if name == "" {
+		reply(fmt.Sprintf(lang.CurrentTimeZone, b.chatLocation(chatID).String()))
+		return
+	}
+
+	if _, err := loadTimeZone(name); err != nil {
+		reply(lang.TimeZoneUsage)
+		return
+	}
+
+	err := b.chatRepo.SetTimeZone(b.ctx, chatID, name)
+	if errors.Is(err, repository.ErrChatNotFound) {
+		reply(lang.ChatNotInitialized)
+		return
+	}
+	if err != nil {
+		slog.Error("handle /timezone command", slog.String("error", err.Error()))
+		b.sendFailedStub(chatID, msgID)
+		return
+	}
+
+	reply(fmt.Sprintf(lang.TimeZoneChanged, name))
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Заголовок, в котором Telegram передаёт secret_token, указанный при регистрации вебхука
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// startWebhook регистрирует вебхук в Telegram и поднимает HTTP сервер, принимающий обновления
+func (b *Botik) startWebhook() error {
+	hookURL, err := url.Parse(b.cfg.Telegram.Webhook.URL)
+	if err != nil {
+		return fmt.Errorf("parse webhook url: %w", err)
+	}
+
+	secret := b.cfg.Telegram.Webhook.Secret
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return fmt.Errorf("generate webhook secret: %w", err)
+		}
+	}
+
+	// Сервер поднимается до регистрации вебхука, чтобы не терять первые обновления
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", b.cfg.Telegram.Webhook.Port))
+	if err != nil {
+		return fmt.Errorf("listen webhook port: %w", err)
+	}
+
+	updates := make(chan tgbotapi.Update, b.bot.Buffer)
+
+	path := hookURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, b.webhookHandler(secret, updates))
+
+	b.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := b.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("webhook server stopped", slog.String("error", err.Error()))
+		}
+	}()
+
+	// tgbotapi.WebhookConfig не поддерживает secret_token, поэтому запрос собирается вручную
+	params := tgbotapi.Params{
+		"url":          hookURL.String(),
+		"secret_token": secret,
+	}
+	if err = params.AddInterface("allowed_updates", allowedUpdates); err != nil {
+		_ = b.server.Close()
+		return fmt.Errorf("encode allowed updates: %w", err)
+	}
+
+	_, err = b.bot.MakeRequest("setWebhook", params)
+	if err != nil {
+		_ = b.server.Close()
+		return fmt.Errorf("set webhook: %w", err)
+	}
+
+	if err := b.checkWebhook(hookURL.String()); err != nil {
+		_ = b.server.Close()
+		return err
+	}
+
+	b.updates = updates
+	return nil
+}
+
+// checkWebhook сверяет состояние вебхука на стороне Telegram с ожидаемым
+func (b *Botik) checkWebhook(expectedURL string) error {
+	info, err := b.bot.GetWebhookInfo()
+	if err != nil {
+		return fmt.Errorf("get webhook info: %w", err)
+	}
+
+	if info.URL != expectedURL {
+		return fmt.Errorf("webhook is registered for %q instead of %q", info.URL, expectedURL)
+	}
+
+	if info.LastErrorDate != 0 {
+		slog.Warn(
+			"Telegram reported webhook delivery error",
+			slog.String("error", info.LastErrorMessage),
+			slog.Time("at", time.Unix(int64(info.LastErrorDate), 0)),
+		)
+	}
+
+	slog.Info(
+		"Webhook registered",
+		slog.String("url", info.URL),
+		slog.Int("pending_updates", info.PendingUpdateCount),
+	)
+
+	return nil
+}
+
+// webhookHandler принимает обновления от Telegram, отклоняя запросы без верного секрета
+func (b *Botik) webhookHandler(secret string, updates chan<- tgbotapi.Update) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			slog.Warn("webhook request with invalid secret token", slog.String("remote", r.RemoteAddr))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		update, err := b.bot.HandleUpdate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Telegram повторит доставку, если не получит ответ 2xx
+		select {
+		case updates <- *update:
+		case <-b.stop:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
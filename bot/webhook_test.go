@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const testWebhookSecret = "test-secret"
+
+// freePort возвращает порт, свободный на момент вызова
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// startWebhookBot запускает бота в режиме вебхука. Обновления, переданные диспетчеру, попадают в канал
+func startWebhookBot(t *testing.T) (b *Botik, hookURL string, api *fakeAPI, dispatched <-chan tgbotapi.Update) {
+	t.Helper()
+
+	api = newFakeAPI(t)
+	cfg := newTestConfig(api)
+	cfg.Telegram.Webhook.Port = freePort(t)
+	cfg.Telegram.Webhook.URL = "https://example.com/hook"
+	cfg.Telegram.Webhook.Secret = testWebhookSecret
+
+	b = newTestBotik(t, cfg, newTestDB(t))
+
+	updates := make(chan tgbotapi.Update, 1)
+	b.dispatcher = newDispatcher(1, 1, func(update tgbotapi.Update) { updates <- update })
+
+	if err := b.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := b.Stop(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return b, fmt.Sprintf("http://127.0.0.1:%d/hook", cfg.Telegram.Webhook.Port), api, updates
+}
+
+// postUpdate отправляет обновление на вебхук так, как это делает Telegram
+func postUpdate(t *testing.T, hookURL, secret, body string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, hookURL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+const testUpdate = `{"update_id": 42, "message": {"message_id": 5, "date": 0, "chat": {"id": -100, "type": "group"}, "from": {"id": 7, "first_name": "Петя"}, "text": "привет"}}`
+
+func TestWebhookRegistersSecret(t *testing.T) {
+	_, _, api, _ := startWebhookBot(t)
+
+	calls := api.callsTo("setWebhook")
+	if len(calls) != 1 {
+		t.Fatalf("setWebhook called %d times, want once", len(calls))
+	}
+	params := calls[0].Params
+	if params.Get("url") != "https://example.com/hook" || params.Get("secret_token") != testWebhookSecret {
+		t.Errorf("setWebhook params %v", params)
+	}
+}
+
+func TestWebhookPassesUpdateToDispatcher(t *testing.T) {
+	_, hookURL, _, dispatched := startWebhookBot(t)
+
+	if code := postUpdate(t, hookURL, testWebhookSecret, testUpdate); code != http.StatusOK {
+		t.Fatalf("webhook answered %d, want %d", code, http.StatusOK)
+	}
+
+	select {
+	case update := <-dispatched:
+		if update.UpdateID != 42 || update.Message == nil || update.Message.Text != "привет" || update.Message.Chat.ID != -100 {
+			t.Errorf("dispatched update %+v", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("update was not passed to dispatcher")
+	}
+}
+
+func TestWebhookRejectsInvalidRequests(t *testing.T) {
+	_, hookURL, _, dispatched := startWebhookBot(t)
+
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		code   int
+	}{
+		{name: "missing secret", body: testUpdate, code: http.StatusForbidden},
+		{name: "wrong secret", secret: "guess", body: testUpdate, code: http.StatusForbidden},
+		{name: "secret prefix", secret: testWebhookSecret[:4], body: testUpdate, code: http.StatusForbidden},
+		{name: "malformed update", secret: testWebhookSecret, body: "{", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := postUpdate(t, hookURL, tt.secret, tt.body); code != tt.code {
+				t.Errorf("webhook answered %d, want %d", code, tt.code)
+			}
+		})
+	}
+
+	select {
+	case update := <-dispatched:
+		t.Errorf("rejected request reached dispatcher: %+v", update)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -1,16 +1,50 @@
 package config
 
-import "github.com/caarlos0/env/v11"
+import (
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
 
 type Config struct {
 	Debug bool `env:"DEBUG" envDefault:"false"`
+	// Сколько ждать завершения обработчиков при остановке
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"10s"`
+
+	// Количество воркеров, параллельно обрабатывающих обновления разных чатов
+	Workers int `env:"WORKERS" envDefault:"4"`
+	// Размер очереди обновлений каждого воркера
+	QueueSize int `env:"QUEUE_SIZE" envDefault:"64"`
+
+	// Минимальные роли для операций, например "create:member,delete:owner".
+	// Не указанные операции используют значения по умолчанию
+	Permissions map[string]string `env:"PERMISSIONS"`
+
+	// Часовой пояс по умолчанию, в котором пользователи вводят и видят сроки заданий.
+	// Чат может выбрать свой командой /timezone
+	TimeZone string `env:"TIMEZONE" envDefault:"Europe/Moscow"`
+
+	// Как часто проверять сроки заданий и создавать повторяющиеся задания
+	SchedulerInterval time.Duration `env:"SCHEDULER_INTERVAL" envDefault:"1m"`
+
+	Reminders struct {
+		// За сколько до срока напоминать о задании
+		Offsets []time.Duration `env:"REMINDER_OFFSETS" envDefault:"24h,1h"`
+		// Отправлять напоминания исполнителю в личные сообщения, а не в чат задания
+		Private bool `env:"REMINDER_PRIVATE" envDefault:"false"`
+	}
 
 	Telegram struct {
 		Token string `env:"TOKEN,required"`
+		// Шаблон адреса Bot API, позволяет подключить бота к локальному серверу
+		APIEndpoint string `env:"API_ENDPOINT" envDefault:"https://api.telegram.org/bot%s/%s"`
 
+		// Если URL не задан, бот получает обновления через long polling
 		Webhook struct {
 			URL  string `env:"URL"`
 			Port int    `env:"PORT" envDefault:"3000"`
+			// Секрет, который Telegram передаёт в заголовке каждого запроса. Если не задан, генерируется при запуске
+			Secret string `env:"WEBHOOK_SECRET"`
 		}
 	}
 
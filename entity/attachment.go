@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// AttachmentKind Тип файла, приложенного к заданию
+type AttachmentKind string
+
+const (
+	AttachmentPhoto    AttachmentKind = "photo"
+	AttachmentDocument AttachmentKind = "document"
+)
+
+// Attachment Фото или документ, приложенный к заданию. Файл хранится в Telegram и отправляется повторно по FileID
+type Attachment struct {
+	ID        int64
+	TaskID    int64
+	Kind      AttachmentKind
+	FileID    string
+	FileName  string // Имя документа, пустое для фото
+	Caption   string
+	Proof     bool // Приложено исполнителем как подтверждение выполнения
+	UserID    int64
+	CreatedAt time.Time
+}
@@ -1,10 +1,63 @@
 package entity
 
+import "time"
+
 type Chat struct {
-	ID    int64   // ID чата
-	Users []int64 // ID пользователей в чате
+	ID       int64  // ID чата
+	Active   bool   // false, если бота удалили из чата
+	TimeZone string // Часовой пояс для сроков, пустой если используется пояс по умолчанию
+	Currency string // Название валюты наград, пустое если используется валюта по умолчанию
+}
+
+func NewChat(ID int64) Chat {
+	return Chat{ID: ID, Active: true}
+}
+
+// MemberRole Роль участника в чате
+type MemberRole string
+
+const (
+	RoleOwner  MemberRole = "owner"  // Создатель чата в Telegram
+	RoleAdmin  MemberRole = "admin"  // Администратор чата
+	RoleMember MemberRole = "member" // Обычный участник
+	RoleViewer MemberRole = "viewer" // Может только смотреть задания
+)
+
+var roleRanks = map[MemberRole]int{
+	RoleViewer: 0,
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+// AtLeast проверяет, что роль не ниже указанной
+func (r MemberRole) AtLeast(min MemberRole) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[min]
+}
+
+// ParseRole разбирает название роли, ok == false для неизвестных ролей
+func ParseRole(s string) (MemberRole, bool) {
+	role := MemberRole(s)
+	_, ok := roleRanks[role]
+	return role, ok
+}
+
+// ChatMember Участник чата, известный боту
+type ChatMember struct {
+	ChatID   int64
+	UserID   int64
+	Role     MemberRole
+	JoinedAt time.Time // Время последнего вступления в чат
+	Active   bool      // false, если участник покинул чат
 }
 
-func NewChat(ID int64, users []int64) Chat {
-	return Chat{ID: ID, Users: users}
+func NewChatMember(chatID, userID int64, role MemberRole) ChatMember {
+	return ChatMember{
+		ChatID:   chatID,
+		UserID:   userID,
+		Role:     role,
+		JoinedAt: time.Now(),
+		Active:   true,
+	}
 }
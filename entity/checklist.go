@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// ChecklistItem Пункт чек-листа задания
+type ChecklistItem struct {
+	ID        int64
+	TaskID    int64
+	Position  int // Порядковый номер пункта в чек-листе, начиная с 1
+	Text      string
+	Done      bool
+	DoneBy    int64     // Кто отметил пункт, 0 если пункт не отмечен
+	DoneAt    time.Time // Когда отмечен пункт, нулевое если пункт не отмечен
+	CreatedAt time.Time
+}
+
+// Checklist Пункты чек-листа задания по порядку
+type Checklist []ChecklistItem
+
+// Progress возвращает число отмеченных пунктов и общее число пунктов
+func (c Checklist) Progress() (done, total int) {
+	for _, item := range c {
+		if item.Done {
+			done++
+		}
+	}
+	return done, len(c)
+}
+
+// Complete проверяет, что чек-лист не пустой и все его пункты отмечены
+func (c Checklist) Complete() bool {
+	done, total := c.Progress()
+	return total > 0 && done == total
+}
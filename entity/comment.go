@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// Comment Комментарий к заданию, оставленный ответом на сообщение бота
+type Comment struct {
+	ID        int64
+	TaskID    int64
+	ChatID    int64
+	MessageID int // ID сообщения с комментарием в чате
+	UserID    int64
+	Text      string
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package entity
+
+// Conversation Состояние многошагового диалога пользователя с ботом в конкретном чате
+type Conversation struct {
+	ChatID int64             // ID чата
+	UserID int64             // ID пользователя
+	State  string            // Текущий шаг диалога
+	Data   map[string]string // Значения, введённые на предыдущих шагах
+}
+
+func NewConversation(chatID, userID int64, state string) Conversation {
+	return Conversation{
+		ChatID: chatID,
+		UserID: userID,
+		State:  state,
+		Data:   make(map[string]string),
+	}
+}
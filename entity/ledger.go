@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// LedgerKind Вид записи в журнале баланса
+type LedgerKind string
+
+const (
+	LedgerTaskReward LedgerKind = "task_reward" // Награда за принятое задание
+	LedgerAdjustment LedgerKind = "adjustment"  // Ручное начисление или списание администратором
+	LedgerPurchase   LedgerKind = "purchase"    // Покупка приза
+	LedgerRefund     LedgerKind = "refund"      // Возврат за отменённую покупку
+)
+
+// LedgerEntry Запись в журнале баланса участника. Amount положительный для начислений и отрицательный для списаний
+type LedgerEntry struct {
+	ID           int64
+	ChatID       int64
+	UserID       int64
+	Amount       int64
+	Kind         LedgerKind
+	TaskID       int64  // Задание, за которое начислена награда, 0 для остальных записей
+	RedemptionID int64  // Покупка приза, 0 для остальных записей
+	Reason       string // Причина ручной корректировки или название купленного приза
+	CreatedBy    int64
+	CreatedAt    time.Time
+}
+
+// Balance Баланс участника чата
+type Balance struct {
+	UserID int64
+	Amount int64
+}
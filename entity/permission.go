@@ -0,0 +1,62 @@
+package entity
+
+import "fmt"
+
+// Action Операция в чате, доступ к которой определяется ролью участника
+type Action string
+
+const (
+	ActionView    Action = "view"    // Просмотр заданий
+	ActionCreate  Action = "create"  // Создание заданий
+	ActionEdit    Action = "edit"    // Изменение чужих заданий
+	ActionDelete  Action = "delete"  // Удаление заданий
+	ActionAssign  Action = "assign"  // Назначение исполнителя
+	ActionApprove Action = "approve" // Приёмка и возврат чужих заданий
+	ActionManage  Action = "manage"  // Настройка чата и ролей участников
+	ActionAdjust  Action = "adjust"  // Ручное изменение баланса участников
+	ActionRedeem  Action = "redeem"  // Покупка призов за награды
+	ActionComment Action = "comment" // Комментарии к заданиям
+)
+
+// Policy Минимальная роль, необходимая для каждой операции
+type Policy map[Action]MemberRole
+
+func DefaultPolicy() Policy {
+	return Policy{
+		ActionView:    RoleViewer,
+		ActionCreate:  RoleMember,
+		ActionEdit:    RoleAdmin,
+		ActionDelete:  RoleAdmin,
+		ActionAssign:  RoleMember,
+		ActionApprove: RoleAdmin,
+		ActionManage:  RoleAdmin,
+		ActionAdjust:  RoleAdmin,
+		ActionRedeem:  RoleMember,
+		ActionComment: RoleMember,
+	}
+}
+
+// NewPolicy накладывает правила вида "операция: роль" на политику по умолчанию
+func NewPolicy(rules map[string]string) (Policy, error) {
+	policy := DefaultPolicy()
+	for rawAction, rawRole := range rules {
+		action := Action(rawAction)
+		if _, ok := policy[action]; !ok {
+			return nil, fmt.Errorf("unknown action %q", rawAction)
+		}
+
+		role := MemberRole(rawRole)
+		if _, ok := roleRanks[role]; !ok {
+			return nil, fmt.Errorf("unknown role %q for action %q", rawRole, rawAction)
+		}
+
+		policy[action] = role
+	}
+	return policy, nil
+}
+
+// Allows проверяет, достаточно ли роли для выполнения операции
+func (p Policy) Allows(action Action, role MemberRole) bool {
+	required, ok := p[action]
+	return ok && role.AtLeast(required)
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// UnlimitedStock Запас приза не ограничен
+const UnlimitedStock = -1
+
+// Prize Приз из каталога чата
+type Prize struct {
+	ID        int64
+	ChatID    int64
+	Title     string
+	Price     int64
+	Stock     int64 // Сколько призов осталось, UnlimitedStock если запас не ограничен
+	Active    bool  // false, если приз убран из каталога
+	CreatedBy int64
+	CreatedAt time.Time
+}
+
+// InStock проверяет, что приз ещё можно купить
+func (p Prize) InStock() bool {
+	return p.Stock == UnlimitedStock || p.Stock > 0
+}
+
+// RedemptionStatus Состояние покупки приза
+type RedemptionStatus string
+
+const (
+	RedemptionPending   RedemptionStatus = "pending"   // Ждёт администратора
+	RedemptionFulfilled RedemptionStatus = "fulfilled" // Приз выдан
+	RedemptionRefunded  RedemptionStatus = "refunded"  // Покупка отменена, сумма возвращена
+)
+
+// Redemption Покупка приза участником
+type Redemption struct {
+	ID         int64
+	ChatID     int64
+	PrizeID    int64
+	UserID     int64
+	Title      string // Название приза на момент покупки
+	Price      int64  // Списанная сумма
+	Status     RedemptionStatus
+	ResolvedBy int64 // Администратор, выдавший приз или вернувший сумму
+	ResolvedAt time.Time
+	CreatedAt  time.Time
+}
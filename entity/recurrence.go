@@ -0,0 +1,236 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRecurrence правило повторения записано с ошибкой
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+// RecurrenceFreq Единица периода повторения
+type RecurrenceFreq string
+
+const (
+	FreqDaily   RecurrenceFreq = "DAILY"
+	FreqWeekly  RecurrenceFreq = "WEEKLY"
+	FreqMonthly RecurrenceFreq = "MONTHLY"
+)
+
+// Recurrence Правило повторения задания. Хранится в виде, похожем на RRULE:
+// "FREQ=WEEKLY;INTERVAL=1;BYDAY=TU,TH", "FREQ=MONTHLY;BYMONTHDAY=15",
+// "FREQ=DAILY;INTERVAL=3;X-AFTER-COMPLETION=TRUE"
+type Recurrence struct {
+	Freq     RecurrenceFreq
+	Interval int            // Каждые Interval дней, недель или месяцев
+	Weekdays []time.Weekday // Дни недели для FreqWeekly, пусто если день берётся из начала серии
+	MonthDay int            // День месяца для FreqMonthly, 0 если день берётся из начала серии
+
+	// Следующее задание отсчитывается от выполнения предыдущего, а не по календарю. Только для FreqDaily
+	AfterCompletion bool
+}
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRecurrence разбирает правило, сохранённое методом String
+func ParseRecurrence(rule string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Recurrence{}, fmt.Errorf("%w: %q", ErrInvalidRecurrence, part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = RecurrenceFreq(value)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				wd := slices.Index(weekdayCodes, code)
+				if wd < 0 {
+					return Recurrence{}, fmt.Errorf("%w: unknown weekday %q", ErrInvalidRecurrence, code)
+				}
+				r.Weekdays = append(r.Weekdays, time.Weekday(wd))
+			}
+		case "BYMONTHDAY":
+			r.MonthDay, err = strconv.Atoi(value)
+		case "X-AFTER-COMPLETION":
+			r.AfterCompletion = value == "TRUE"
+		default:
+			return Recurrence{}, fmt.Errorf("%w: unknown part %q", ErrInvalidRecurrence, key)
+		}
+		if err != nil {
+			return Recurrence{}, fmt.Errorf("%w: %s", ErrInvalidRecurrence, err)
+		}
+	}
+
+	return r, r.Validate()
+}
+
+// Validate проверяет, что правило задаёт хотя бы одно вхождение в каждом периоде
+func (r Recurrence) Validate() error {
+	switch {
+	case r.Freq != FreqDaily && r.Freq != FreqWeekly && r.Freq != FreqMonthly:
+		return fmt.Errorf("%w: unknown frequency %q", ErrInvalidRecurrence, r.Freq)
+	case r.Interval < 1:
+		return fmt.Errorf("%w: interval must be positive", ErrInvalidRecurrence)
+	case r.MonthDay < 0 || r.MonthDay > 31:
+		return fmt.Errorf("%w: month day out of range", ErrInvalidRecurrence)
+	case r.AfterCompletion && r.Freq != FreqDaily:
+		return fmt.Errorf("%w: only daily rules can follow completion", ErrInvalidRecurrence)
+	}
+	return nil
+}
+
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq), "INTERVAL=" + strconv.Itoa(r.Interval)}
+
+	if len(r.Weekdays) > 0 {
+		codes := make([]string, 0, len(r.Weekdays))
+		for _, wd := range r.Weekdays {
+			codes = append(codes, weekdayCodes[wd])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.MonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.MonthDay))
+	}
+	if r.AfterCompletion {
+		parts = append(parts, "X-AFTER-COMPLETION=TRUE")
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next возвращает первое вхождение серии, начатой в start, строго после after.
+// Время суток и часовой пояс берутся из start. Для правил после выполнения after — время выполнения
+func (r Recurrence) Next(start, after time.Time) time.Time {
+	after = after.In(start.Location())
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, start.Location())
+	}
+
+	if r.AfterCompletion {
+		return at(after.AddDate(0, 0, r.Interval))
+	}
+
+	switch r.Freq {
+	case FreqWeekly:
+		weekdays := r.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+
+		day := at(start)
+		if after.After(day) {
+			day = at(after)
+		}
+		// За два периода обязательно встретится подходящий день
+		for i := 0; i < 14*r.Interval; i++ {
+			if day.After(after) && slices.Contains(weekdays, day.Weekday()) && weeksBetween(start, day)%r.Interval == 0 {
+				return day
+			}
+			day = at(day.AddDate(0, 0, 1))
+		}
+		return time.Time{}
+	case FreqMonthly:
+		monthDay := r.MonthDay
+		if monthDay == 0 {
+			monthDay = start.Day()
+		}
+
+		for months := 0; ; months += r.Interval {
+			first := time.Date(start.Year(), start.Month()+time.Month(months), 1, 0, 0, 0, 0, start.Location())
+			// В коротких месяцах вхождение переносится на последний день
+			day := at(first.AddDate(0, 0, min(monthDay, daysIn(first))-1))
+			if day.After(after) && !day.Before(at(start)) {
+				return day
+			}
+		}
+	default:
+		day := at(start)
+		for !day.After(after) {
+			day = at(day.AddDate(0, 0, r.Interval))
+		}
+		return day
+	}
+}
+
+// weeksBetween количество календарных недель, начинающихся с понедельника, между датами
+func weeksBetween(from, to time.Time) int {
+	monday := func(t time.Time) time.Time {
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+	}
+	return int(monday(to).Sub(monday(from)).Hours() / (24 * 7))
+}
+
+func daysIn(month time.Time) int {
+	return time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// SeriesStatus Состояние серии повторяющихся заданий
+type SeriesStatus string
+
+const (
+	SeriesActive SeriesStatus = "active" // Новые задания создаются
+	SeriesPaused SeriesStatus = "paused" // Создание приостановлено, его можно возобновить
+	SeriesEnded  SeriesStatus = "ended"  // Серия завершена навсегда
+)
+
+// Series Серия повторяющихся заданий. Каждое следующее задание создаётся по шаблону серии
+type Series struct {
+	ID           int64
+	ChatID       int64
+	Rule         Recurrence
+	Title        string
+	Description  string
+	Reward       string
+	RewardAmount int64
+	AssigneeID   int64
+	StartAt      time.Time // Срок первого задания, задаёт время суток следующих
+	Status       SeriesStatus
+	LastTaskID   int64 // Последнее созданное задание серии
+	CreatedBy    int64
+	CreatedAt    time.Time
+}
+
+// NewSeries создаёт серию, первым заданием которой становится task
+func NewSeries(task *Task, rule Recurrence, startAt time.Time) Series {
+	return Series{
+		ChatID:       task.ChatID,
+		Rule:         rule,
+		Title:        task.Title,
+		Description:  task.Description,
+		Reward:       task.Reward,
+		RewardAmount: task.RewardAmount,
+		AssigneeID:   task.AssigneeID,
+		StartAt:      startAt,
+		Status:       SeriesActive,
+		LastTaskID:   task.ID,
+		CreatedBy:    task.CreatedBy,
+	}
+}
+
+// NextTask задание серии со сроком dueAt
+func (s Series) NextTask(dueAt time.Time) *Task {
+	return &Task{
+		ChatID:       s.ChatID,
+		Title:        s.Title,
+		Description:  s.Description,
+		Reward:       s.Reward,
+		RewardAmount: s.RewardAmount,
+		AssigneeID:   s.AssigneeID,
+		Status:       StatusOpen,
+		DueAt:        dueAt,
+		SeriesID:     s.ID,
+		CreatedBy:    s.CreatedBy,
+	}
+}
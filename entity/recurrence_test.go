@@ -0,0 +1,197 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestRecurrenceNext(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(year int, month time.Month, day, hour, minute int, loc *time.Location) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+	// Вторник, 3 марта 2026
+	tuesday := date(2026, time.March, 3, 10, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		rule  Recurrence
+		start time.Time
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name: "daily before start returns start", rule: Recurrence{Freq: FreqDaily, Interval: 1},
+			start: tuesday, after: tuesday.AddDate(0, 0, -1), want: tuesday,
+		},
+		{
+			name: "daily strictly after", rule: Recurrence{Freq: FreqDaily, Interval: 1},
+			start: tuesday, after: tuesday, want: date(2026, time.March, 4, 10, 0, time.UTC),
+		},
+		{
+			name: "daily later the same day", rule: Recurrence{Freq: FreqDaily, Interval: 1},
+			start: tuesday, after: date(2026, time.March, 3, 12, 0, time.UTC), want: date(2026, time.March, 4, 10, 0, time.UTC),
+		},
+		{
+			name: "every 3 days keeps phase", rule: Recurrence{Freq: FreqDaily, Interval: 3},
+			start: tuesday, after: date(2026, time.March, 10, 9, 0, time.UTC), want: date(2026, time.March, 12, 10, 0, time.UTC),
+		},
+		{
+			name: "daily across year", rule: Recurrence{Freq: FreqDaily, Interval: 2},
+			start: date(2026, time.December, 30, 8, 0, time.UTC), after: date(2026, time.December, 30, 9, 0, time.UTC),
+			want: date(2027, time.January, 1, 8, 0, time.UTC),
+		},
+		{
+			name: "weekly on start weekday", rule: Recurrence{Freq: FreqWeekly, Interval: 1},
+			start: tuesday, after: tuesday, want: date(2026, time.March, 10, 10, 0, time.UTC),
+		},
+		{
+			name: "weekly on two days", rule: Recurrence{Freq: FreqWeekly, Interval: 1, Weekdays: []time.Weekday{time.Tuesday, time.Thursday}},
+			start: tuesday, after: tuesday, want: date(2026, time.March, 5, 10, 0, time.UTC),
+		},
+		{
+			name: "weekly rolls to next week", rule: Recurrence{Freq: FreqWeekly, Interval: 1, Weekdays: []time.Weekday{time.Tuesday, time.Thursday}},
+			start: tuesday, after: date(2026, time.March, 5, 10, 0, time.UTC), want: date(2026, time.March, 10, 10, 0, time.UTC),
+		},
+		{
+			name: "every 2 weeks within first week", rule: Recurrence{Freq: FreqWeekly, Interval: 2, Weekdays: []time.Weekday{time.Monday, time.Friday}},
+			start: tuesday, after: tuesday, want: date(2026, time.March, 6, 10, 0, time.UTC),
+		},
+		{
+			name: "every 2 weeks skips odd week", rule: Recurrence{Freq: FreqWeekly, Interval: 2, Weekdays: []time.Weekday{time.Monday, time.Friday}},
+			start: tuesday, after: date(2026, time.March, 6, 10, 0, time.UTC), want: date(2026, time.March, 16, 10, 0, time.UTC),
+		},
+		{
+			name: "every 2 weeks across year", rule: Recurrence{Freq: FreqWeekly, Interval: 2, Weekdays: []time.Weekday{time.Monday}},
+			start: date(2026, time.December, 29, 10, 0, time.UTC), after: date(2026, time.December, 29, 10, 0, time.UTC),
+			want: date(2027, time.January, 11, 10, 0, time.UTC),
+		},
+		{
+			name: "monthly on 31st in february", rule: Recurrence{Freq: FreqMonthly, Interval: 1},
+			start: date(2026, time.January, 31, 10, 0, time.UTC), after: date(2026, time.January, 31, 10, 0, time.UTC),
+			want: date(2026, time.February, 28, 10, 0, time.UTC),
+		},
+		{
+			name: "monthly on 31st returns after short month", rule: Recurrence{Freq: FreqMonthly, Interval: 1},
+			start: date(2026, time.January, 31, 10, 0, time.UTC), after: date(2026, time.February, 28, 10, 0, time.UTC),
+			want: date(2026, time.March, 31, 10, 0, time.UTC),
+		},
+		{
+			name: "monthly on 31st in april", rule: Recurrence{Freq: FreqMonthly, Interval: 1, MonthDay: 31},
+			start: date(2026, time.January, 31, 10, 0, time.UTC), after: date(2026, time.March, 31, 10, 0, time.UTC),
+			want: date(2026, time.April, 30, 10, 0, time.UTC),
+		},
+		{
+			name: "monthly on 29th in leap year", rule: Recurrence{Freq: FreqMonthly, Interval: 1, MonthDay: 29},
+			start: date(2028, time.January, 29, 10, 0, time.UTC), after: date(2028, time.January, 29, 10, 0, time.UTC),
+			want: date(2028, time.February, 29, 10, 0, time.UTC),
+		},
+		{
+			name: "monthly on 29th in common year", rule: Recurrence{Freq: FreqMonthly, Interval: 1, MonthDay: 29},
+			start: date(2027, time.January, 29, 10, 0, time.UTC), after: date(2027, time.January, 29, 10, 0, time.UTC),
+			want: date(2027, time.February, 28, 10, 0, time.UTC),
+		},
+		{
+			name: "monthly day before start day begins next month", rule: Recurrence{Freq: FreqMonthly, Interval: 1, MonthDay: 10},
+			start: date(2026, time.January, 20, 10, 0, time.UTC), after: date(2026, time.January, 1, 0, 0, time.UTC),
+			want: date(2026, time.February, 10, 10, 0, time.UTC),
+		},
+		{
+			name: "every 3 months", rule: Recurrence{Freq: FreqMonthly, Interval: 3, MonthDay: 15},
+			start: date(2026, time.January, 15, 10, 0, time.UTC), after: date(2026, time.April, 20, 0, 0, time.UTC),
+			want: date(2026, time.July, 15, 10, 0, time.UTC),
+		},
+		{
+			name: "monthly across year", rule: Recurrence{Freq: FreqMonthly, Interval: 2},
+			start: date(2026, time.November, 30, 10, 0, time.UTC), after: date(2026, time.November, 30, 10, 0, time.UTC),
+			want: date(2027, time.January, 30, 10, 0, time.UTC),
+		},
+		{
+			name: "after completion keeps time of day", rule: Recurrence{Freq: FreqDaily, Interval: 3, AfterCompletion: true},
+			start: tuesday, after: date(2026, time.March, 5, 18, 45, time.UTC), want: date(2026, time.March, 8, 10, 0, time.UTC),
+		},
+		{
+			name: "after is converted to start zone", rule: Recurrence{Freq: FreqDaily, Interval: 1},
+			start: date(2026, time.March, 3, 10, 0, msk), after: date(2026, time.March, 3, 8, 0, time.UTC),
+			want: date(2026, time.March, 4, 10, 0, msk),
+		},
+		{
+			name: "daylight saving keeps wall clock", rule: Recurrence{Freq: FreqDaily, Interval: 1},
+			start: date(2026, time.March, 28, 9, 0, berlin), after: date(2026, time.March, 28, 9, 0, berlin),
+			want: date(2026, time.March, 29, 9, 0, berlin),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.Next(tt.start, tt.after)
+			if !got.Equal(tt.want) || got.Location() != tt.want.Location() {
+				t.Errorf("Next(%s, %s) = %s, want %s", tt.start, tt.after, got, tt.want)
+			}
+			if !got.After(tt.after) {
+				t.Errorf("Next(%s, %s) = %s is not after %s", tt.start, tt.after, got, tt.after)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRoundTrip(t *testing.T) {
+	tests := []struct {
+		rule string
+		want Recurrence
+	}{
+		{rule: "FREQ=DAILY;INTERVAL=1", want: Recurrence{Freq: FreqDaily, Interval: 1}},
+		{rule: "FREQ=DAILY;INTERVAL=3;X-AFTER-COMPLETION=TRUE", want: Recurrence{Freq: FreqDaily, Interval: 3, AfterCompletion: true}},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", want: Recurrence{Freq: FreqWeekly, Interval: 2, Weekdays: []time.Weekday{time.Tuesday, time.Thursday}}},
+		{rule: "FREQ=WEEKLY;INTERVAL=1;BYDAY=SU", want: Recurrence{Freq: FreqWeekly, Interval: 1, Weekdays: []time.Weekday{time.Sunday}}},
+		{rule: "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=31", want: Recurrence{Freq: FreqMonthly, Interval: 1, MonthDay: 31}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q) error: %v", tt.rule, err)
+			}
+			if got.String() != tt.want.String() || got.AfterCompletion != tt.want.AfterCompletion {
+				t.Errorf("ParseRecurrence(%q) = %+v, want %+v", tt.rule, got, tt.want)
+			}
+			if got.String() != tt.rule {
+				t.Errorf("String() = %q, want %q", got.String(), tt.rule)
+			}
+		})
+	}
+
+	// Интервал по умолчанию
+	got, err := ParseRecurrence("FREQ=MONTHLY")
+	if err != nil || got.Interval != 1 {
+		t.Errorf("ParseRecurrence without interval = %+v, %v, want interval 1", got, err)
+	}
+}
+
+func TestParseRecurrenceErrors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"FREQ",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=-2",
+		"FREQ=DAILY;INTERVAL=abc",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=-1",
+		"FREQ=WEEKLY;X-AFTER-COMPLETION=TRUE",
+		"FREQ=DAILY;COUNT=3",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			if _, err := ParseRecurrence(rule); !errors.Is(err, ErrInvalidRecurrence) {
+				t.Errorf("ParseRecurrence(%q) error = %v, want ErrInvalidRecurrence", rule, err)
+			}
+		})
+	}
+}
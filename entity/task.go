@@ -1,13 +1,124 @@
 package entity
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTransitionNotAllowed = errors.New("status transition is not allowed")
+	ErrActorNotAllowed      = errors.New("user is not allowed to perform this transition")
+)
+
+// TaskStatus Этап жизненного цикла задания
+type TaskStatus string
+
+const (
+	StatusOpen       TaskStatus = "open"        // Создано, никто не взялся
+	StatusInProgress TaskStatus = "in_progress" // Исполнитель взял в работу
+	StatusDone       TaskStatus = "done"        // Исполнитель отметил выполнение
+	StatusAccepted   TaskStatus = "accepted"    // Автор принял результат
+)
+
+// transition Допустимый переход между статусами и кто может его выполнить.
+// Переходы приёмки доступны автору задания и тем, кому политика разрешает ActionApprove
+type transition struct {
+	From       TaskStatus
+	To         TaskStatus
+	ByAssignee bool
+	ByApprover bool
+}
+
+var transitions = []transition{
+	{From: StatusOpen, To: StatusInProgress, ByAssignee: true},
+	{From: StatusOpen, To: StatusDone, ByAssignee: true},
+	{From: StatusInProgress, To: StatusDone, ByAssignee: true},
+	{From: StatusDone, To: StatusAccepted, ByApprover: true},
+	{From: StatusDone, To: StatusOpen, ByApprover: true},
+}
+
+// Actor Участник чата, действующий над заданием
+type Actor struct {
+	UserID int64
+	Role   MemberRole
+}
 
 type Task struct {
-	ID          int64
-	Title       string
-	Description string
-	Reward      string
-	Assignee    string
-	CreatedBy   int64
-	CreatedAt   time.Time
+	ID                int64
+	ChatID            int64 // ID чата, в котором создано задание
+	Title             string
+	Description       string
+	Reward            string // Текстовая награда заданий, созданных до появления баланса
+	RewardAmount      int64  // Награда в валюте чата, 0 если награды нет
+	AssigneeID        int64  // ID исполнителя, 0 если исполнитель не привязан к пользователю
+	Assignee          string // Текстовый исполнитель заданий, созданных до привязки к пользователям
+	Status            TaskStatus
+	NeedsReassignment bool      // Исполнитель покинул чат, заданию нужен новый
+	DueAt             time.Time // Срок выполнения, нулевой если срок не задан
+	SeriesID          int64     // Серия повторяющихся заданий, 0 если задание разовое
+	AutoComplete      bool      // Отметить задание выполненным, когда отмечены все пункты чек-листа
+	Version           int64     // Растёт с каждым изменением полей, защищает от одновременных правок
+	CreatedBy         int64
+	CreatedAt         time.Time
+}
+
+// HasDeadline проверяет, задан ли у задания срок
+func (t *Task) HasDeadline() bool {
+	return !t.DueAt.IsZero()
+}
+
+// IsCompleted проверяет, что исполнитель выполнил задание
+func (t *Task) IsCompleted() bool {
+	return t.Status == StatusDone || t.Status == StatusAccepted
+}
+
+// IsActive проверяет, что задание ещё не выполнено
+func (t *Task) IsActive() bool {
+	return t.Status == StatusOpen || t.Status == StatusInProgress
+}
+
+// StatusChange Запись о смене статуса задания
+type StatusChange struct {
+	TaskID    int64
+	From      TaskStatus
+	To        TaskStatus
+	ChangedBy int64 // ID пользователя, сменившего статус
+	ChangedAt time.Time
+}
+
+// IsAssignee проверяет, что пользователь является исполнителем задания
+func (t *Task) IsAssignee(userID int64) bool {
+	return t.AssigneeID != 0 && t.AssigneeID == userID
+}
+
+// NextStatuses возвращает статусы, в которые задание может перейти из текущего
+func (t *Task) NextStatuses() []TaskStatus {
+	var next []TaskStatus
+	for _, tr := range transitions {
+		if tr.From == t.Status {
+			next = append(next, tr.To)
+		}
+	}
+	return next
+}
+
+// CanApprove проверяет, может ли участник принимать выполнение задания
+func (t *Task) CanApprove(actor Actor, policy Policy) bool {
+	return t.CreatedBy == actor.UserID || policy.Allows(ActionApprove, actor.Role)
+}
+
+// CanTransition проверяет, может ли участник перевести задание в статус to
+func (t *Task) CanTransition(to TaskStatus, actor Actor, policy Policy) error {
+	for _, tr := range transitions {
+		if tr.From != t.Status || tr.To != to {
+			continue
+		}
+
+		if (tr.ByAssignee && t.IsAssignee(actor.UserID)) || (tr.ByApprover && t.CanApprove(actor, policy)) {
+			return nil
+		}
+		return ErrActorNotAllowed
+	}
+
+	return ErrTransitionNotAllowed
 }
@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	var (
+		creator  = Actor{UserID: 1, Role: RoleMember}
+		assignee = Actor{UserID: 2, Role: RoleMember}
+		admin    = Actor{UserID: 3, Role: RoleAdmin}
+		owner    = Actor{UserID: 4, Role: RoleOwner}
+		member   = Actor{UserID: 5, Role: RoleMember}
+		viewer   = Actor{UserID: 6, Role: RoleViewer}
+	)
+
+	// Участникам разрешено принимать чужие задания
+	membersApprove := DefaultPolicy()
+	membersApprove[ActionApprove] = RoleMember
+
+	tests := []struct {
+		name     string
+		from, to TaskStatus
+		actor    Actor
+		policy   Policy
+		unowned  bool // У задания нет исполнителя
+		want     error
+	}{
+		// Работу над заданием ведёт только исполнитель
+		{name: "assignee takes task", from: StatusOpen, to: StatusInProgress, actor: assignee},
+		{name: "assignee completes open task", from: StatusOpen, to: StatusDone, actor: assignee},
+		{name: "assignee completes task in progress", from: StatusInProgress, to: StatusDone, actor: assignee},
+		{name: "creator cannot take task", from: StatusOpen, to: StatusInProgress, actor: creator, want: ErrActorNotAllowed},
+		{name: "admin cannot complete task", from: StatusInProgress, to: StatusDone, actor: admin, want: ErrActorNotAllowed},
+		{name: "owner cannot complete task", from: StatusOpen, to: StatusDone, actor: owner, want: ErrActorNotAllowed},
+		{name: "member cannot take task", from: StatusOpen, to: StatusInProgress, actor: member, want: ErrActorNotAllowed},
+		{name: "nobody works on unassigned task", from: StatusOpen, to: StatusInProgress, actor: Actor{Role: RoleMember}, unowned: true, want: ErrActorNotAllowed},
+
+		// Принимает и возвращает выполненное задание автор или тот, кому это разрешает политика
+		{name: "creator accepts", from: StatusDone, to: StatusAccepted, actor: creator},
+		{name: "creator reopens", from: StatusDone, to: StatusOpen, actor: creator},
+		{name: "admin accepts", from: StatusDone, to: StatusAccepted, actor: admin},
+		{name: "owner reopens", from: StatusDone, to: StatusOpen, actor: owner},
+		{name: "assignee cannot accept own work", from: StatusDone, to: StatusAccepted, actor: assignee, want: ErrActorNotAllowed},
+		{name: "member cannot accept", from: StatusDone, to: StatusAccepted, actor: member, want: ErrActorNotAllowed},
+		{name: "viewer cannot reopen", from: StatusDone, to: StatusOpen, actor: viewer, want: ErrActorNotAllowed},
+		{name: "member accepts when policy allows", from: StatusDone, to: StatusAccepted, actor: member, policy: membersApprove},
+		{name: "viewer still cannot accept", from: StatusDone, to: StatusAccepted, actor: viewer, policy: membersApprove, want: ErrActorNotAllowed},
+
+		// Переходов, которых нет в таблице, не может выполнить никто
+		{name: "task in progress is not reopened", from: StatusInProgress, to: StatusOpen, actor: assignee, want: ErrTransitionNotAllowed},
+		{name: "open task is not accepted", from: StatusOpen, to: StatusAccepted, actor: creator, want: ErrTransitionNotAllowed},
+		{name: "task in progress is not accepted", from: StatusInProgress, to: StatusAccepted, actor: admin, want: ErrTransitionNotAllowed},
+		{name: "done task is not taken again", from: StatusDone, to: StatusInProgress, actor: assignee, want: ErrTransitionNotAllowed},
+		{name: "accepted task is final", from: StatusAccepted, to: StatusOpen, actor: owner, want: ErrTransitionNotAllowed},
+		{name: "accepted task is not undone", from: StatusAccepted, to: StatusDone, actor: assignee, want: ErrTransitionNotAllowed},
+		{name: "same status", from: StatusOpen, to: StatusOpen, actor: assignee, want: ErrTransitionNotAllowed},
+		{name: "unknown status", from: StatusOpen, to: TaskStatus("archived"), actor: owner, want: ErrTransitionNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Status: tt.from, AssigneeID: assignee.UserID, CreatedBy: creator.UserID}
+			if tt.unowned {
+				task.AssigneeID = 0
+			}
+			policy := tt.policy
+			if policy == nil {
+				policy = DefaultPolicy()
+			}
+
+			if err := task.CanTransition(tt.to, tt.actor, policy); !errors.Is(err, tt.want) {
+				t.Errorf("CanTransition(%s -> %s) = %v, want %v", tt.from, tt.to, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextStatuses(t *testing.T) {
+	tests := []struct {
+		from TaskStatus
+		want []TaskStatus
+	}{
+		{StatusOpen, []TaskStatus{StatusInProgress, StatusDone}},
+		{StatusInProgress, []TaskStatus{StatusDone}},
+		{StatusDone, []TaskStatus{StatusAccepted, StatusOpen}},
+		{StatusAccepted, nil},
+	}
+	for _, tt := range tests {
+		task := &Task{Status: tt.from}
+		if got := task.NextStatuses(); !slices.Equal(got, tt.want) {
+			t.Errorf("NextStatuses(%s) = %v, want %v", tt.from, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// User Пользователь Telegram, известный боту
+type User struct {
+	ID        int64  // ID пользователя в Telegram
+	Username  string // Username без @, может быть пустым
+	FirstName string
+	LastName  string
+
+	LanguageCode string    // Язык клиента Telegram, может быть пустым
+	LastSeenAt   time.Time // Время последнего сообщения или нажатия кнопки, нулевое если не известно
+}
+
+// DisplayName имя для показа в кнопках и сообщениях
+func (u User) DisplayName() string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	switch {
+	case name != "":
+		return name
+	case u.Username != "":
+		return "@" + u.Username
+	default:
+		return strconv.FormatInt(u.ID, 10)
+	}
+}
+
+// Mention упоминание пользователя в тексте сообщения
+func (u User) Mention() string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return u.DisplayName()
+}
@@ -6,6 +6,7 @@ require (
 	github.com/caarlos0/env/v11 v11.3.1
 	github.com/glebarez/go-sqlite v1.22.0
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	modernc.org/sqlite v1.28.0
 )
 
 require (
@@ -17,5 +18,4 @@ require (
 	modernc.org/libc v1.37.6 // indirect
 	modernc.org/mathutil v1.6.0 // indirect
 	modernc.org/memory v1.7.2 // indirect
-	modernc.org/sqlite v1.28.0 // indirect
 )
@@ -4,15 +4,245 @@ const (
 	Start = "Начало работы"
 	Help  = "Помощь"
 
+	MainMenu       = "🏠 Главное меню:"
+	ButtonTaskList = "📝 Список заданий"
+	ButtonNewTask  = "➕ Создать задание"
+	ButtonMenu     = "🏠 В меню"
+
 	FailedStub = "Что-то пошло не так. Попробуйте повторить позже"
 
+	Forbidden = "У вас нет прав на это действие"
+
+	MalformedCallback = "Некорректные данные кнопки"
+	UnknownAction     = "Неизвестное действие"
+
 	BotAddedToGroup = "Спасибо за добавление в чат! Я готов к работе."
 
-	DetailedTask = `📌 Задание #%d
-					🔹 Название: %s
-					🔹 Описание: %s
-					🔹 Награда: %s
-					🔹 Исполнитель: %s
-					🔹 Создано: %s
-					`
+	ChatInitialized    = "Чат инициализирован, можно создавать задания командой /new"
+	ChatNotInitialized = "Сначала инициализируйте чат командой /init_chat"
+
+	RoleUsage      = "Использование: /role @username роль. Роли: admin, member, viewer"
+	RoleChanged    = "Роль %s теперь %s"
+	OwnerRoleFixed = "Роль владельца чата изменить нельзя"
+	UserNotFound   = "Пользователь не найден. Он должен хотя бы раз написать в чат"
+
+	LinkAssigneeUsage    = "Использование: /link_assignee @username исполнитель, как он записан в задании. Например: /link_assignee @petya Петя"
+	LegacyAssigneeLinked = "Задания с исполнителем «%s» привязаны к %s: %d"
+	NoLegacyAssignee     = "Заданий с исполнителем «%s» без привязки к пользователю нет"
+	OrphanTasksAdopted   = "В чат перенесены задания, созданные его участниками до привязки к чатам: %d"
+	NoOrphanTasks        = "Заданий без чата, созданных участниками этого чата, нет"
+
+	CurrentTimeZone = "Часовой пояс чата: %s. Изменить: /timezone Europe/Moscow или /timezone UTC+3"
+	TimeZoneChanged = "Часовой пояс чата изменён на %s"
+	TimeZoneUsage   = "Неизвестный часовой пояс. Укажите, например, Europe/Moscow или UTC+3"
+
+	RepeatUsage        = "Использование: /repeat <номер> <правило>. Например: /repeat 12 каждый вторник в 10:00, /repeat 12 каждый месяц 1 числа, /repeat 12 через 3 дня после выполнения"
+	TaskAlreadyRepeats = "Задание уже входит в серию, управлять ей можно через /series"
+	SeriesCreated      = "🔁 Задание #%d теперь повторяется: %s. Ближайший срок: %s"
+	SeriesNextTask     = "🔁 Новое задание серии #%d"
+	SeriesListHeader   = "🔁 Повторяющиеся задания:"
+	SeriesLine         = "Серия %d: «%s», %s"
+	SeriesPausedMark   = "(на паузе)"
+	NoSeries           = "В чате нет повторяющихся заданий. Сделать задание повторяющимся: /repeat <номер> <правило>"
+	SeriesNotFound     = "Серия не найдена или уже завершена"
+	SeriesPaused       = "Серия приостановлена"
+	SeriesResumed      = "Серия возобновлена"
+	SeriesEnded        = "Серия завершена"
+	ButtonPauseSeries  = "⏸ Серия %d"
+	ButtonResumeSeries = "▶️ Серия %d"
+	ButtonEndSeries    = "⏹ Завершить %d"
+
+	RecurDaily           = "каждый день"
+	RecurEveryNDays      = "каждые %d дн."
+	RecurWeekly          = "каждую неделю"
+	RecurEveryNWeeks     = "каждые %d нед."
+	RecurMonthly         = "каждый месяц"
+	RecurEveryNMonths    = "каждые %d мес."
+	RecurMonthDay        = "%d числа"
+	RecurAfterCompletion = "через %d дн. после выполнения"
+	RecurAt              = "в %s"
+
+	DefaultCurrency   = "⭐"
+	Amount            = "%d %s"
+	SignedAmount      = "%+d %s"
+	NoReward          = "без награды"
+	RewardCredited    = "🎉 %s получает %s за задание #%d"
+	BalanceLine       = "💰 Баланс %s: %s"
+	LedgerTaskReward  = "%s за задание #%d"
+	LedgerAdjustment  = "%s: %s"
+	LeaderboardHeader = "🏆 Рейтинг участников:"
+	LeaderboardLine   = "%d. %s — %s"
+	LeaderboardEmpty  = "В этом чате ещё никто ничего не заработал"
+	AdjustUsage       = "Использование: /adjust @username <сумма> <причина>. Например: /adjust @ivan -50 штраф за опоздание"
+	BalanceAdjusted   = "Баланс %s изменён на %s (%s). Теперь: %s"
+	CurrencyUsage     = "Текущая валюта: %s. Изменить: /currency <название>, не длиннее 16 символов"
+	CurrencyChanged   = "Валюта наград теперь: %s"
+
+	LedgerPurchase       = "%s: покупка «%s»"
+	LedgerRefund         = "%s: возврат за «%s»"
+	ShopHeader           = "🛍 Магазин призов. Выберите приз:"
+	ShopEmpty            = "В магазине пока нет призов. Администратор может добавить их командой /prize_add"
+	PrizeButton          = "%s — %s"
+	PrizeStock           = "(осталось %d)"
+	PrizeSoldOut         = "(нет в наличии)"
+	PrizeAddUsage        = "Использование: /prize_add <цена> [x<количество>] <название>. Например: /prize_add 500 x3 Выходной день"
+	PrizeAdded           = "Приз %d добавлен: %s"
+	PrizeRemoveUsage     = "Использование: /prize_remove <номер приза>"
+	PrizeRemoved         = "Приз убран из магазина"
+	PrizeNotFound        = "Приз не найден"
+	PrizeOutOfStock      = "Этот приз закончился"
+	ConfirmPurchase      = "Купить «%s» за %s? Ваш баланс: %s"
+	ButtonBuy            = "✅ Купить"
+	ButtonBack           = "⬅️ Назад"
+	InsufficientFunds    = "Недостаточно средств на балансе"
+	PurchaseDone         = "Покупка оформлена, ждите выдачи"
+	RedemptionRequested  = "🛍 %s покупает «%s» за %s. Заявка #%d ждёт администратора"
+	ButtonFulfill        = "✅ Выдано"
+	ButtonRefund         = "↩️ Вернуть средства"
+	ButtonFulfillOrder   = "✅ #%d"
+	ButtonRefundOrder    = "↩️ #%d"
+	OrdersHeader         = "🧾 Заявки на призы:"
+	OrderLine            = "#%d %s: «%s» за %s"
+	NoOrders             = "Необработанных заявок нет"
+	OrderNotFound        = "Заявка не найдена"
+	OrderAlreadyResolved = "Заявка уже обработана"
+	OrderFulfilled       = "Приз выдан"
+	OrderRefunded        = "Средства возвращены"
+	OrderFulfilledStatus = "✅ Выдано, отметил(а) %s"
+	OrderRefundedStatus  = "↩️ Средства возвращены, отметил(а) %s"
+
+	ChecklistProgress     = "☑️ Чек-лист: %d/%d"
+	ChecklistAutoComplete = ", задание завершится само"
+	ChecklistItem         = "%s %d. %s"
+	ChecklistChecked      = "✅"
+	ChecklistUnchecked    = "⬜"
+	ChecklistItemsAdded   = "Пунктов добавлено в чек-лист: %d"
+	ChecklistItemRemoved  = "Пункт удалён из чек-листа"
+	ChecklistItemNotFound = "Пункт чек-листа не найден"
+	ChecklistLocked       = "Задание уже выполнено, чек-лист не меняется"
+	ChecklistCompleted    = "Все пункты отмечены, задание выполнено"
+	CheckUsage            = "Использование: /check <номер задания> <пункт>. Несколько пунктов пишите с новой строки"
+	CheckRemoveUsage      = "Использование: /check_remove <номер задания> <номер пункта>"
+	AutoDoneUsage         = "Использование: /autodone <номер задания>"
+	AutoDoneEnabled       = "Задание будет выполнено автоматически, когда отмечены все пункты чек-листа"
+	AutoDoneDisabled      = "Автоматическое выполнение по чек-листу выключено"
+
+	ButtonComments      = "💬 Комментарии"
+	ButtonCommentsCount = "💬 Комментарии (%d)"
+	CommentsHeader      = "💬 Комментарии к заданию #%d «%s». Ответьте на карточку задания, чтобы добавить свой"
+	NoComments          = "Комментариев пока нет"
+	CommentLine         = "%s, %s:\n%s"
+	CommentSaved        = "💬 Комментарий к заданию #%d сохранён"
+	CommentNotification = "💬 %s прокомментировал(а) задание #%d «%s»:\n%s"
+
+	TaskDoneNotification = "✅ %s выполнил(а) задание #%d «%s»"
+
+	TaskAttachmentsLine    = "📎 Вложений: %d"
+	ButtonAttach           = "📎 Приложить"
+	ButtonAttachments      = "🗂 Файлы (%d)"
+	ButtonSkip             = "Пропустить"
+	AskAttachment          = "📎 Пришлите фото или документ к заданию #%d"
+	AskProof               = "📎 Задание #%d отмечено выполненным. Пришлите фото или документ как подтверждение или нажмите «Пропустить»"
+	AttachmentExpected     = "Пришлите фото или документ или нажмите «Пропустить»"
+	AttachmentSkipped      = "Задание осталось без вложения"
+	AttachmentSaved        = "📎 Вложение добавлено к заданию #%d"
+	ProofSaved             = "📎 К заданию #%d приложено подтверждение выполнения"
+	ProofCaption           = "Подтверждение выполнения задания #%d\n%s"
+	NoAttachments          = "К заданию ничего не приложено"
+	AttachmentsSent        = "Вложений: %d"
+	ConversationInProgress = "Сначала завершите или отмените текущий диалог с ботом"
+
+	NoTasks                = "В чате пока нет заданий. Создать задание: /new"
+	NoTasksFound           = "Подходящих заданий нет. Фильтры: /tasks мои, /tasks активные, /tasks срок, остальные слова ищутся в названии и описании"
+	NoAssignee             = "без исполнителя"
+	TaskListHeader         = "📝 Задания чата, страница %d:"
+	TaskListFilteredHeader = "🔎 Найденные задания, страница %d:"
+	TaskListLine           = "#%d %s — %s, %s"
+	TaskListButton         = "#%d %s"
+	ButtonPrevPage         = "⬅️ Назад"
+	ButtonNextPage         = "Вперёд ➡️"
+
+	ButtonEdit          = "✏️ Изменить"
+	ButtonDelete        = "🗑 Удалить"
+	ButtonConfirmDelete = "🗑 Да, удалить"
+	ButtonBackToList    = "📝 К списку"
+	ConfirmDelete       = "Удалить задание #%d «%s»? Это действие нельзя отменить"
+	TaskDeleted         = "🗑 Задание #%d удалено"
+	Deleted             = "Удалено"
+	TaskAcceptedLocked  = "Принятое задание изменить нельзя"
+	TaskUpdated         = "✏️ Задание #%d изменено"
+	TaskEditConflict    = "Задание изменили, пока вы его редактировали. Откройте карточку и попробуйте снова"
+
+	EditMenu           = "✏️ Что изменить в задании #%d «%s»?"
+	ButtonEditTitle    = "Название"
+	ButtonEditDesc     = "Описание"
+	ButtonEditReward   = "Награда"
+	ButtonEditDue      = "Срок"
+	ButtonEditAssignee = "Исполнитель"
+	ButtonNoAssignee   = "🚫 Снять исполнителя"
+	AskNewTitle        = "✏️ Сейчас: «%s»\nВведите новое название задания #%d, не длиннее %d символов:"
+	AskNewDesc         = "✏️ Сейчас: «%s»\nВведите новое описание задания #%d, не длиннее %d символов:"
+	AskNewReward       = "✏️ Сейчас: %s\nВведите новую награду за задание #%d числом, в %s. 0 — без награды:"
+	AskNewDue          = "✏️ Сейчас: %s\nУкажите новый срок задания #%d, например «завтра в 18:00» или «25.12», или «нет», чтобы убрать срок:"
+	AskNewAssignee     = "✏️ Кому передать задание #%d «%s»?"
+	TitleTooLong       = "Название длиннее %d символов, сократите его"
+	DescTooLong        = "Описание длиннее %d символов, сократите его"
+	TaskDueUpdated     = "✏️ Срок задания #%d: %s, %s"
+	TaskDueRemoved     = "✏️ У задания #%d больше нет срока"
+
+	MemberLeftWithTasks = "%s покинул(а) чат. Незавершённых заданий: %d, их нужно переназначить"
+
+	Cancel          = "❌ Отмена"
+	Cancelled       = "Действие отменено"
+	NothingToCancel = "Нечего отменять"
+	AskTaskTitle    = "Введите название задания:"
+	AskTaskDesc     = "Введите описание задания:"
+	AskTaskReward   = "Введите награду за выполнение числом, в %s. 0 — без награды:"
+	InvalidReward   = "Награда должна быть целым неотрицательным числом, например 100"
+	AskTaskDueDate  = "Укажите срок, например «завтра в 18:00», «в пятницу», «через 3 дня» или «25.12», или нажмите «Без срока»:"
+	NoDueDate       = "Без срока"
+	InvalidDueDate  = "Не удалось понять срок. Попробуйте «завтра в 18:00», «в пятницу», «через 3 дня» или «25.12 10:00»"
+	DueDateInPast   = "Срок уже прошёл, укажите время в будущем"
+	ConfirmDueDate  = "Срок: %s, %s. Всё верно? Если нет, напишите срок точнее"
+	ConfirmDue      = "✅ Верно"
+	AskTaskAssignee = "Кому назначено задание? Выберите исполнителя:"
+	PickAssignee    = "Выберите исполнителя кнопкой под вопросом"
+	NotYourDialog   = "Эта кнопка относится к чужому диалогу"
+	NotChatMember   = "Этот пользователь не участник чата"
+	EmptyValue      = "Значение не может быть пустым, попробуйте ещё раз"
+	TaskCreated     = "Задание #%d создано!"
+
+	TaskNotFound         = "Задание не найдено"
+	TaskUsage            = "Укажите номер задания: /task <номер>"
+	TransitionNotAllowed = "Из текущего статуса так перевести задание нельзя"
+	TaskChanged          = "Задание уже изменили, карточка обновлена"
+
+	StatusOpen       = "Открыто"
+	StatusInProgress = "В работе"
+	StatusDone       = "Выполнено"
+	StatusAccepted   = "Принято"
+
+	ButtonTake   = "▶️ Взять в работу"
+	ButtonDone   = "✅ Выполнено"
+	ButtonAccept = "👍 Принять"
+	ButtonReopen = "↩️ Вернуть"
+
+	ReminderBeforeDue = "⏰ Срок задания #%d «%s» — %s"
+	ReminderOverdue   = "⚠️ Задание #%d «%s» просрочено, срок был %s"
+
+	TaskStatusLine        = "🔹 Статус: %s"
+	TaskDueLine           = "🔹 Срок: %s"
+	TaskSeriesLine        = "🔁 Повторяется, серия %d"
+	TaskNeedsReassignment = "⚠️ Исполнитель покинул чат, назначьте нового"
+
+	DetailedTask = "📌 <b>Задание #%d</b>\n" +
+		"🔹 Название: %s\n" +
+		"🔹 Описание: %s\n" +
+		"🔹 Награда: %s\n" +
+		"🔹 Исполнитель: %s\n" +
+		"🔹 Автор: %s\n" +
+		"🔹 Создано: %s\n"
 )
+
+// WeekdaysShort Краткие названия дней недели в порядке time.Weekday, начиная с воскресенья
+var WeekdaysShort = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
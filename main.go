@@ -1,41 +1,46 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/qrave1/task-track/bot"
 	"github.com/qrave1/task-track/config"
+	"github.com/qrave1/task-track/migrations"
 	"github.com/qrave1/task-track/repository"
 )
 
-func migrateDB(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS tasks (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			title TEXT NOT NULL,
-			description TEXT,
-			reward TEXT,
-			assignee TEXT NOT NULL,
-			created_by INTEGER NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);
-		
-		CREATE TABLE IF NOT EXISTS chats (
-		    id INTEGER PRIMARY KEY,
-		    users TEXT NOT NULL
-		)
-	`)
-
-	return err
+// printPendingMigrations выводит миграции, которые будут применены при следующем запуске
+func printPendingMigrations(ctx context.Context, db *sql.DB) error {
+	pending, err := migrations.Pending(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("No pending migrations")
+		return nil
+	}
+
+	for _, m := range pending {
+		fmt.Printf("%04d_%s\n", m.Version, m.Name)
+	}
+	return nil
 }
 
 func main() {
+	listMigrations := flag.Bool("pending-migrations", false, "print pending database migrations and exit")
+	flag.Parse()
+
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
@@ -54,7 +59,21 @@ func main() {
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
 
-	err = migrateDB(db)
+	// Связанные с заданием записи удаляются каскадно вместе с ним
+	if _, err = db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		slog.Error("failed to enable foreign keys", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if *listMigrations {
+		if err = printPendingMigrations(context.Background(), db); err != nil {
+			slog.Error("failed to list migrations", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = migrations.Apply(context.Background(), db)
 	if err != nil {
 		slog.Error("failed to migrate database", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -62,17 +81,44 @@ func main() {
 
 	taskRepo := repository.NewTaskRepositoryImpl(db)
 	chatRepo := repository.NewChatRepositoryImpl(db)
+	conversationRepo := repository.NewConversationRepositoryImpl(db)
+	userRepo := repository.NewUserRepositoryImpl(db)
+	reminderRepo := repository.NewReminderRepositoryImpl(db)
+	seriesRepo := repository.NewSeriesRepositoryImpl(db)
+	ledgerRepo := repository.NewLedgerRepositoryImpl(db)
+	shopRepo := repository.NewShopRepositoryImpl(db)
+	checklistRepo := repository.NewChecklistRepositoryImpl(db)
+	commentRepo := repository.NewCommentRepositoryImpl(db)
+	attachmentRepo := repository.NewAttachmentRepositoryImpl(db)
+	taskListRepo := repository.NewTaskListRepositoryImpl(db)
 
-	b, err := bot.NewBotik(cfg, taskRepo, chatRepo)
+	b, err := bot.NewBotik(
+		cfg, taskRepo, chatRepo, conversationRepo, userRepo, reminderRepo, seriesRepo, ledgerRepo, shopRepo, checklistRepo,
+		commentRepo, attachmentRepo, taskListRepo,
+	)
 	if err != nil {
 		slog.Error("failed to create bot", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	b.Start()
+	if err = b.Start(); err != nil {
+		slog.Error("failed to start bot", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 	slog.Info("Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err = b.Stop(ctx); err != nil {
+		slog.Error("failed to stop bot gracefully", slog.String("error", err.Error()))
+	}
+
+	if err = db.Close(); err != nil {
+		slog.Error("failed to close database", slog.String("error", err.Error()))
+	}
 }
@@ -0,0 +1,15 @@
+-- Кэш профилей пользователей Telegram для отображения исполнителей
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    username TEXT NOT NULL DEFAULT '',
+    first_name TEXT NOT NULL DEFAULT '',
+    last_name TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Исполнитель теперь хранится как ID пользователя. Колонка assignee остаётся для заданий
+-- со старым текстовым исполнителем: они привязываются к пользователю, когда бот узнаёт
+-- его username или имя, совпадающее с текстом
+ALTER TABLE tasks ADD COLUMN assignee_id INTEGER;
+
+CREATE INDEX idx_tasks_assignee_id ON tasks (chat_id, assignee_id);
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN language_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN last_seen_at TIMESTAMP;
@@ -0,0 +1,17 @@
+CREATE TABLE chat_members (
+    chat_id INTEGER NOT NULL REFERENCES chats (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    active INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE INDEX idx_chat_members_user_id ON chat_members (user_id);
+
+-- Список users заполнялся из GetChatAdministrators, поэтому все перенесённые участники администраторы
+INSERT INTO chat_members (chat_id, user_id, role)
+SELECT chats.id, json_each.value, 'admin'
+FROM chats, json_each(chats.users);
+
+ALTER TABLE chats DROP COLUMN users;
@@ -0,0 +1,5 @@
+-- Чат деактивируется, когда бота удаляют из него, и активируется при повторном добавлении
+ALTER TABLE chats ADD COLUMN active INTEGER NOT NULL DEFAULT 1;
+
+-- Незавершённые задания участника, покинувшего чат, ждут нового исполнителя
+ALTER TABLE tasks ADD COLUMN needs_reassignment INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+-- Срок выполнения задания, NULL если срок не задан. Хранится в UTC
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_due_at ON tasks (due_at) WHERE due_at IS NOT NULL;
+
+-- Отправленные напоминания о сроке. Срок входит в ключ, чтобы после его переноса напоминания пришли заново
+CREATE TABLE IF NOT EXISTS task_reminders (
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    due_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, kind, due_at)
+);
@@ -0,0 +1,2 @@
+-- Часовой пояс чата в формате IANA или смещения от UTC. Пустая строка означает пояс из настроек бота
+ALTER TABLE chats ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
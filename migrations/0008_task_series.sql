@@ -0,0 +1,19 @@
+-- Серии повторяющихся заданий. Следующее задание серии создаётся по шаблону из этой таблицы
+CREATE TABLE IF NOT EXISTS task_series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    rule TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    reward TEXT NOT NULL DEFAULT '',
+    assignee_id INTEGER,
+    start_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    last_task_id INTEGER,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_series_chat_id ON task_series (chat_id, status);
+
+ALTER TABLE tasks ADD COLUMN series_id INTEGER REFERENCES task_series (id) ON DELETE SET NULL;
@@ -0,0 +1,38 @@
+-- Награда в валюте чата. Текстовая награда остаётся у старых заданий, числовые значения переносятся
+ALTER TABLE tasks ADD COLUMN reward_amount INTEGER NOT NULL DEFAULT 0;
+
+UPDATE tasks SET reward_amount = CAST(trim(reward) AS INTEGER), reward = ''
+WHERE trim(reward) != '' AND trim(reward) NOT GLOB '*[^0-9]*' AND length(trim(reward)) <= 15;
+
+ALTER TABLE task_series ADD COLUMN reward_amount INTEGER NOT NULL DEFAULT 0;
+
+-- Название валюты чата. Пустая строка означает валюту по умолчанию
+ALTER TABLE chats ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+
+-- Журнал начислений и списаний. Записи только добавляются, баланс считается как сумма
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    task_id INTEGER,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_chat_user ON ledger_entries (chat_id, user_id);
+
+-- За каждое задание награда начисляется один раз
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_task_reward ON ledger_entries (task_id) WHERE kind = 'task_reward';
+
+CREATE TRIGGER IF NOT EXISTS ledger_entries_no_update BEFORE UPDATE ON ledger_entries
+BEGIN
+    SELECT RAISE(ABORT, 'ledger entries are append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS ledger_entries_no_delete BEFORE DELETE ON ledger_entries
+BEGIN
+    SELECT RAISE(ABORT, 'ledger entries are append-only');
+END;
@@ -0,0 +1,35 @@
+-- Призы, которые участники чата покупают за накопленные награды. stock = NULL означает неограниченный запас
+CREATE TABLE IF NOT EXISTS prizes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    stock INTEGER CHECK (stock >= 0),
+    active INTEGER NOT NULL DEFAULT 1,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_prizes_chat_id ON prizes (chat_id, active);
+
+-- Покупки призов. Цена запоминается на момент покупки, чтобы возврат не зависел от изменений каталога
+CREATE TABLE IF NOT EXISTS redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    prize_id INTEGER NOT NULL REFERENCES prizes (id),
+    user_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    resolved_by INTEGER,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_redemptions_chat_status ON redemptions (chat_id, status);
+
+ALTER TABLE ledger_entries ADD COLUMN redemption_id INTEGER;
+
+-- Покупка списывается и возвращается не больше одного раза
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_redemption ON ledger_entries (redemption_id, kind)
+WHERE redemption_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Завершать задание, когда отмечены все пункты чек-листа
+ALTER TABLE tasks ADD COLUMN auto_complete INTEGER NOT NULL DEFAULT 0;
+
+-- Пункты чек-листа задания в порядке добавления
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    done INTEGER NOT NULL DEFAULT 0,
+    done_by INTEGER,
+    done_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_items_task ON checklist_items (task_id, position);
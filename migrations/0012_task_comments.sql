@@ -0,0 +1,22 @@
+-- Сообщения бота, относящиеся к заданиям: карточки и напоминания. Ответ на такое сообщение становится комментарием
+CREATE TABLE IF NOT EXISTS task_messages (
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_messages_task ON task_messages (task_id);
+
+-- Комментарии к заданиям
+CREATE TABLE IF NOT EXISTS task_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task ON task_comments (task_id, id);
@@ -0,0 +1,14 @@
+-- Фото и документы, приложенные к заданиям. Сам файл хранится в Telegram, здесь только его file_id
+CREATE TABLE IF NOT EXISTS task_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    file_id TEXT NOT NULL,
+    file_name TEXT NOT NULL DEFAULT '',
+    caption TEXT NOT NULL DEFAULT '',
+    proof INTEGER NOT NULL DEFAULT 0,
+    user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_attachments_task ON task_attachments (task_id, id);
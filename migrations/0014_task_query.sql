@@ -0,0 +1,36 @@
+-- Индексы для выборки заданий чата по фильтрам. Дата создания в конце ключа позволяет
+-- отдавать страницы по курсору без сортировки всей выборки
+DROP INDEX IF EXISTS idx_tasks_assignee_id;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_chat_assignee ON tasks (chat_id, assignee_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_chat_status ON tasks (chat_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_chat_creator ON tasks (chat_id, created_by, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_chat_due ON tasks (chat_id, due_at IS NULL, due_at);
+
+-- Полнотекстовый поиск по названию и описанию. unicode61 приводит к нижнему регистру и кириллицу,
+-- чего не умеют LIKE и lower() в SQLite. Индекс поддерживается триггерами
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5 (
+    title,
+    description,
+    content = 'tasks',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks
+BEGIN
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks
+BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF title, description ON tasks
+BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
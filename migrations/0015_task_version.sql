@@ -0,0 +1,3 @@
+-- Версия задания растёт с каждым изменением полей. Изменение со старой версией отклоняется,
+-- чтобы одновременные правки не затирали друг друга
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,16 @@
+-- Задания со старым текстовым исполнителем вида @username привязываются к участнику их чата
+-- с таким username. Username в Telegram состоит из латиницы, цифр и подчёркиваний, поэтому
+-- lower() сравнивает его без учёта регистра. Остальные задания привязывает администратор
+-- командой /link_assignee
+UPDATE tasks SET assignee_id = (
+    SELECT users.id
+    FROM users
+    JOIN chat_members ON chat_members.user_id = users.id AND chat_members.chat_id = tasks.chat_id
+    WHERE users.username != '' AND lower(users.username) = lower(substr(trim(tasks.assignee), 2))
+), version = version + 1
+WHERE assignee_id IS NULL AND trim(assignee) LIKE '@_%' AND (
+    SELECT COUNT(*)
+    FROM users
+    JOIN chat_members ON chat_members.user_id = users.id AND chat_members.chat_id = tasks.chat_id
+    WHERE users.username != '' AND lower(users.username) = lower(substr(trim(tasks.assignee), 2))
+) = 1;
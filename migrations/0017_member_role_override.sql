@@ -0,0 +1,4 @@
+-- Роль, назначенная командой /role, хранится отдельно от роли из Telegram и имеет приоритет над ней,
+-- кроме роли владельца. Роли, назначенные до этой миграции, неотличимы от ролей из Telegram
+-- и обновятся при следующей смене статуса участника
+ALTER TABLE chat_members ADD COLUMN role_override TEXT;
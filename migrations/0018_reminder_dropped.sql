@@ -0,0 +1,3 @@
+-- Напоминание, которое Telegram отказался доставить насовсем, например потому что бота удалили из чата,
+-- остаётся в таблице с отметкой dropped и больше не отправляется
+ALTER TABLE task_reminders ADD COLUMN dropped INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+-- Сообщения со списком заданий: фильтр и курсоры открытых страниц. Курсор и текст поиска
+-- не помещаются в 64 байта данных кнопки, поэтому кнопки листания передают только направление
+CREATE TABLE IF NOT EXISTS task_lists (
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, message_id)
+);
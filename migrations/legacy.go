@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// adoptLegacy доводит базу, созданную до появления версионных миграций, до схемы первой миграции.
+// Таблицы из 0001_init.sql создаются с IF NOT EXISTS, но колонки, добавленные позже, приходится дописывать вручную
+func adoptLegacy(ctx context.Context, db *sql.DB) error {
+	if err := ensureVersionTable(ctx, db); err != nil {
+		return err
+	}
+
+	var versioned, legacy bool
+	err := db.QueryRowContext(
+		ctx,
+		`SELECT
+			EXISTS (SELECT 1 FROM schema_version),
+			EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'tasks')`,
+	).Scan(&versioned, &legacy)
+	if err != nil || versioned || !legacy {
+		return err
+	}
+
+	// Задания, созданные до привязки к чатам, получают chat_id = 0 и не видны ни в одном чате
+	err = addColumnIfMissing(ctx, db, "tasks", "chat_id", "INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		return err
+	}
+	if err := adoptOrphanTasks(ctx, db); err != nil {
+		return err
+	}
+
+	return addColumnIfMissing(ctx, db, "tasks", "status", "TEXT NOT NULL DEFAULT 'open'")
+}
+
+// adoptOrphanTasks переносит задания без чата в единственный известный чат.
+// Если чатов несколько или нет ни одного, задания остаются без чата до команды /adopt_tasks
+func adoptOrphanTasks(ctx context.Context, db *sql.DB) error {
+	var hasChats bool
+	err := db.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'chats')",
+	).Scan(&hasChats)
+	if err != nil {
+		return err
+	}
+
+	var chats, chatID int64
+	if hasChats {
+		err = db.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(MIN(id), 0) FROM chats").Scan(&chats, &chatID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if chats == 1 {
+		_, err = db.ExecContext(ctx, "UPDATE tasks SET chat_id = ? WHERE chat_id = 0", chatID)
+		return err
+	}
+
+	var orphans int64
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE chat_id = 0").Scan(&orphans); err != nil {
+		return err
+	}
+	if orphans > 0 {
+		slog.Warn(
+			"legacy tasks are not bound to a chat, move them with /adopt_tasks",
+			slog.Int64("tasks", orphans),
+			slog.Int64("chats", chats),
+		)
+	}
+	return nil
+}
+
+func addColumnIfMissing(ctx context.Context, db *sql.DB, table, column, definition string) error {
+	var exists bool
+	err := db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?",
+		table, column,
+	).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,149 @@
+// Package migrations хранит пронумерованные SQL миграции схемы и применяет их к базе.
+// Файлы миграций называются NNNN_описание.sql и встраиваются в бинарник
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+var ErrDatabaseTooNew = errors.New("database schema is newer than the application")
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Load возвращает встроенные миграции, упорядоченные по версии
+func Load() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+
+		rawVersion, title, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %q: name must look like NNNN_title.sql", entry.Name())
+		}
+
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil {
+			return nil, fmt.Errorf("migration %q: invalid version: %w", entry.Name(), err)
+		}
+
+		body, err := files.ReadFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: title, SQL: string(body)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d_%s: expected version %d", m.Version, m.Name, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// CurrentVersion возвращает последнюю применённую к базе версию схемы
+func CurrentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	if err := ensureVersionTable(ctx, db); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+// Pending возвращает миграции, ещё не применённые к базе.
+// Если база создана более новой версией приложения, возвращает ErrDatabaseTooNew
+func Pending(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if current > len(migrations) {
+		return nil, fmt.Errorf("%w: database version %d, latest known %d", ErrDatabaseTooNew, current, len(migrations))
+	}
+
+	return migrations[current:], nil
+}
+
+// Apply применяет к базе все недостающие миграции, каждую в собственной транзакции
+func Apply(ctx context.Context, db *sql.DB) error {
+	if err := adoptLegacy(ctx, db); err != nil {
+		return fmt.Errorf("adopt legacy schema: %w", err)
+	}
+
+	pending, err := Pending(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := apply(ctx, db, m); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_version (version, name) VALUES (?, ?)", m.Version, m.Name)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func ensureVersionTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_version (
+		    version INTEGER PRIMARY KEY,
+		    name TEXT NOT NULL,
+		    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
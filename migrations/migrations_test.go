@@ -0,0 +1,243 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// legacySchema Схема, которую создавало приложение до версионных миграций
+const legacySchema = `
+	CREATE TABLE IF NOT EXISTS tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		description TEXT,
+		reward TEXT,
+		assignee TEXT NOT NULL,
+		created_by INTEGER NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS chats (
+	    id INTEGER PRIMARY KEY,
+	    users TEXT NOT NULL
+	)
+`
+
+// newTestDB открывает пустую базу в памяти
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	// У каждого соединения своя база в памяти
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// latestVersion версия последней встроенной миграции
+func latestVersion(t *testing.T) int {
+	t.Helper()
+
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// assertMigrated проверяет, что к базе применены все миграции ровно по одному разу
+func assertMigrated(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+	latest := latestVersion(t)
+
+	version, err := CurrentVersion(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != latest {
+		t.Errorf("version %d, want %d", version, latest)
+	}
+
+	var applied int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != latest {
+		t.Errorf("%d migrations recorded, want %d", applied, latest)
+	}
+
+	pending, err := Pending(ctx, db)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("pending migrations %v, %v", pending, err)
+	}
+}
+
+func TestApplyFreshDatabase(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := Apply(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	assertMigrated(t, db)
+
+	// Схема последней миграции пригодна для работы
+	if _, err := db.Exec("INSERT INTO tasks (chat_id, title, assignee, created_by) VALUES (-1, 'Полить цветы', '', 7)"); err != nil {
+		t.Fatal(err)
+	}
+	var found int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tasks_fts WHERE tasks_fts MATCH 'цветы'").Scan(&found); err != nil || found != 1 {
+		t.Errorf("search found %d tasks, %v", found, err)
+	}
+}
+
+func TestApplyAdoptsLegacyDatabase(t *testing.T) {
+	db := newTestDB(t)
+
+	if _, err := db.Exec(legacySchema); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.Exec(`
+		INSERT INTO chats (id, users) VALUES (-100, '[7]');
+		INSERT INTO tasks (title, description, reward, assignee, created_by) VALUES
+			('Полить цветы', 'На балконе', 'Шоколадка', '@petya', 7),
+			('Вынести мусор', NULL, NULL, 'Вася', 7);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Apply(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	assertMigrated(t, db)
+
+	// Задания единственного чата переносятся в него и остаются открытыми
+	rows, err := db.Query("SELECT chat_id, status, assignee FROM tasks ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var assignees []string
+	for rows.Next() {
+		var (
+			chatID   int64
+			status   string
+			assignee string
+		)
+		if err := rows.Scan(&chatID, &status, &assignee); err != nil {
+			t.Fatal(err)
+		}
+		if chatID != -100 || status != "open" {
+			t.Errorf("legacy task in chat %d with status %q", chatID, status)
+		}
+		assignees = append(assignees, assignee)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(assignees) != 2 || assignees[0] != "@petya" || assignees[1] != "Вася" {
+		t.Errorf("legacy assignees %v", assignees)
+	}
+
+	// Участники из списка чата и старые задания переносятся в новые таблицы
+	var members, indexed int
+	if err := db.QueryRow("SELECT COUNT(*) FROM chat_members WHERE chat_id = -100 AND user_id = 7").Scan(&members); err != nil || members != 1 {
+		t.Errorf("legacy chat members %d, %v", members, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM tasks_fts WHERE tasks_fts MATCH 'балконе'").Scan(&indexed); err != nil || indexed != 1 {
+		t.Errorf("legacy tasks in search index %d, %v", indexed, err)
+	}
+}
+
+func TestApplyLeavesLegacyTasksOfSeveralChats(t *testing.T) {
+	db := newTestDB(t)
+
+	if _, err := db.Exec(legacySchema); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.Exec(`
+		INSERT INTO chats (id, users) VALUES (-100, '[7]'), (-200, '[8]');
+		INSERT INTO tasks (title, assignee, created_by) VALUES ('Полить цветы', '@petya', 7);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Apply(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	assertMigrated(t, db)
+
+	// Непонятно, какому чату принадлежит задание, его переносит /adopt_tasks
+	var chatID int64
+	if err := db.QueryRow("SELECT chat_id FROM tasks").Scan(&chatID); err != nil || chatID != 0 {
+		t.Errorf("legacy task in chat %d, %v, want none", chatID, err)
+	}
+}
+
+func TestApplyIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if err := Apply(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO tasks (chat_id, title, assignee, created_by) VALUES (-1, 'Полить цветы', '', 7)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Повторный запуск, как при каждом старте приложения, ничего не меняет
+	for range 2 {
+		if err := Apply(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertMigrated(t, db)
+
+	var tasks int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&tasks); err != nil || tasks != 1 {
+		t.Errorf("%d tasks after re-run, %v", tasks, err)
+	}
+}
+
+func TestApplyRefusesNewerDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if err := Apply(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// База обновлена более новой версией приложения
+	newer := latestVersion(t) + 1
+	if _, err := db.Exec("INSERT INTO schema_version (version, name) VALUES (?, 'future')", newer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Apply(ctx, db); !errors.Is(err, ErrDatabaseTooNew) {
+		t.Errorf("Apply error = %v, want ErrDatabaseTooNew", err)
+	}
+	if _, err := Pending(ctx, db); !errors.Is(err, ErrDatabaseTooNew) {
+		t.Errorf("Pending error = %v, want ErrDatabaseTooNew", err)
+	}
+
+	version, err := CurrentVersion(ctx, db)
+	if err != nil || version != newer {
+		t.Errorf("version %d, %v, want %d left as is", version, err, newer)
+	}
+}
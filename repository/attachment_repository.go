@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+type AttachmentRepository interface {
+	Add(ctx context.Context, attachment *entity.Attachment) error
+	List(ctx context.Context, taskID int64, limit int) ([]entity.Attachment, error)
+	Count(ctx context.Context, taskID int64) (int, error)
+}
+
+// AttachmentRepositoryImpl Репозиторий файлов, приложенных к заданиям
+type AttachmentRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepositoryImpl(db *sql.DB) *AttachmentRepositoryImpl {
+	return &AttachmentRepositoryImpl{db: db}
+}
+
+func (r *AttachmentRepositoryImpl) Add(ctx context.Context, attachment *entity.Attachment) error {
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO task_attachments (task_id, kind, file_id, file_name, caption, proof, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at`,
+		attachment.TaskID, attachment.Kind, attachment.FileID, attachment.FileName, attachment.Caption, attachment.Proof,
+		attachment.UserID,
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+}
+
+// List возвращает последние limit вложений задания от старых к новым
+func (r *AttachmentRepositoryImpl) List(ctx context.Context, taskID int64, limit int) ([]entity.Attachment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, task_id, kind, file_id, file_name, caption, proof, user_id, created_at FROM (
+			SELECT * FROM task_attachments WHERE task_id = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id`,
+		taskID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []entity.Attachment
+	for rows.Next() {
+		var a entity.Attachment
+		err := rows.Scan(&a.ID, &a.TaskID, &a.Kind, &a.FileID, &a.FileName, &a.Caption, &a.Proof, &a.UserID, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepositoryImpl) Count(ctx context.Context, taskID int64) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM task_attachments WHERE task_id = ?", taskID).Scan(&count)
+	return count, err
+}
@@ -6,20 +6,38 @@ import (
 	"errors"
 
 	"github.com/qrave1/task-track/entity"
-	v1 "github.com/qrave1/task-track/repository/v1"
 )
 
-var ErrChatNotFound = errors.New("chat not found")
+var (
+	ErrChatNotFound   = errors.New("chat not found")
+	ErrMemberNotFound = errors.New("chat member not found")
+)
 
 type ChatRepository interface {
 	Create(ctx context.Context, chat entity.Chat) error
 	GetByID(ctx context.Context, id int64) (entity.Chat, error)
-	//List() ([]*entity.Chat, error)
-	//Update(task *entity.Chat) error
-	//Delete(id int64) error
+	SetActive(ctx context.Context, id int64, active bool) error
+	SetTimeZone(ctx context.Context, id int64, timeZone string) error
+	SetCurrency(ctx context.Context, id int64, currency string) error
+
+	SaveMember(ctx context.Context, member entity.ChatMember) error
+	SetRole(ctx context.Context, chatID, userID int64, role entity.MemberRole) error
+	DeactivateMember(ctx context.Context, chatID, userID int64) error
+	GetMember(ctx context.Context, chatID, userID int64) (entity.ChatMember, error)
+	Members(ctx context.Context, chatID int64) ([]entity.ChatMember, error)
+	IsMember(ctx context.Context, chatID, userID int64) (bool, error)
+}
+
+// memberColumns Роль владельца определяет Telegram, остальные роли может переопределить команда /role
+const memberColumns = "chat_id, user_id, CASE WHEN role = 'owner' THEN role ELSE COALESCE(role_override, role) END, joined_at, active"
+
+func scanMember(row rowScanner) (entity.ChatMember, error) {
+	var member entity.ChatMember
+	err := row.Scan(&member.ChatID, &member.UserID, &member.Role, &member.JoinedAt, &member.Active)
+	return member, err
 }
 
-// ChatRepositoryImpl Репозиторий для работы с пользователями бота
+// ChatRepositoryImpl Репозиторий для работы с чатами и их участниками
 type ChatRepositoryImpl struct {
 	db *sql.DB
 }
@@ -29,42 +47,149 @@ func NewChatRepositoryImpl(db *sql.DB) *ChatRepositoryImpl {
 }
 
 func (c *ChatRepositoryImpl) Create(ctx context.Context, chat entity.Chat) error {
-	dbChat, err := v1.NewChatFromEntity(chat)
+	_, err := c.db.ExecContext(ctx, `INSERT INTO chats (id, active) VALUES (?, ?)`, chat.ID, chat.Active)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *ChatRepositoryImpl) GetByID(ctx context.Context, id int64) (entity.Chat, error) {
+	var chat entity.Chat
+	err := c.db.QueryRowContext(ctx, "SELECT id, active, timezone, currency FROM chats WHERE id = ?", id).
+		Scan(&chat.ID, &chat.Active, &chat.TimeZone, &chat.Currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Chat{}, ErrChatNotFound
+		}
+		return entity.Chat{}, err
+	}
+
+	return chat, nil
+}
+
+func (c *ChatRepositoryImpl) SetActive(ctx context.Context, id int64, active bool) error {
+	_, err := c.db.ExecContext(ctx, "UPDATE chats SET active = ? WHERE id = ?", active, id)
+	return err
+}
+
+func (c *ChatRepositoryImpl) SetTimeZone(ctx context.Context, id int64, timeZone string) error {
+	return c.setSetting(ctx, id, "timezone", timeZone)
+}
+
+func (c *ChatRepositoryImpl) SetCurrency(ctx context.Context, id int64, currency string) error {
+	return c.setSetting(ctx, id, "currency", currency)
+}
+
+// setSetting меняет текстовую настройку чата. column подставляется только из кода репозитория
+func (c *ChatRepositoryImpl) setSetting(ctx context.Context, id int64, column, value string) error {
+	res, err := c.db.ExecContext(ctx, "UPDATE chats SET "+column+" = ? WHERE id = ?", value, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		return ErrChatNotFound
+	}
+	return nil
+}
+
+// SaveMember добавляет участника или обновляет его роль в Telegram. Роль, назначенная командой /role,
+// при этом сохраняется. Вернувшийся участник снова становится активным, время вступления при этом обновляется
+func (c *ChatRepositoryImpl) SaveMember(ctx context.Context, member entity.ChatMember) error {
+	_, err := c.db.ExecContext(
+		ctx,
+		`INSERT INTO chat_members (chat_id, user_id, role, joined_at, active) VALUES (?, ?, ?, ?, 1)
+		ON CONFLICT (chat_id, user_id) DO UPDATE SET
+			role = excluded.role,
+			joined_at = CASE WHEN chat_members.active THEN chat_members.joined_at ELSE excluded.joined_at END,
+			active = 1`,
+		member.ChatID, member.UserID, member.Role, member.JoinedAt,
+	)
+	return err
+}
 
-	_, err = c.db.ExecContext(
+// SetRole назначает участнику роль, которая действует вместо его роли в Telegram
+func (c *ChatRepositoryImpl) SetRole(ctx context.Context, chatID, userID int64, role entity.MemberRole) error {
+	res, err := c.db.ExecContext(
 		ctx,
-		`INSERT INTO chats (id, users) VALUES (?, ?)`,
-		dbChat.ID,
-		dbChat.Users,
+		"UPDATE chat_members SET role_override = ? WHERE chat_id = ? AND user_id = ?",
+		role, chatID, userID,
 	)
 	if err != nil {
 		return err
 	}
 
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrMemberNotFound
+	}
 	return nil
 }
 
-func (c *ChatRepositoryImpl) GetByID(ctx context.Context, id int64) (entity.Chat, error) {
-	var chat v1.Chat
-	err := c.db.QueryRowContext(
+// DeactivateMember отмечает, что участник покинул чат. Запись сохраняется для истории заданий
+func (c *ChatRepositoryImpl) DeactivateMember(ctx context.Context, chatID, userID int64) error {
+	_, err := c.db.ExecContext(
 		ctx,
-		"SELECT id, users FROM chats WHERE id = ?",
-		id,
-	).Scan(&chat.ID, &chat.Users)
+		"UPDATE chat_members SET active = 0 WHERE chat_id = ? AND user_id = ?",
+		chatID, userID,
+	)
+	return err
+}
+
+func (c *ChatRepositoryImpl) GetMember(ctx context.Context, chatID, userID int64) (entity.ChatMember, error) {
+	member, err := scanMember(c.db.QueryRowContext(
+		ctx,
+		"SELECT "+memberColumns+" FROM chat_members WHERE chat_id = ? AND user_id = ?",
+		chatID, userID,
+	))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return entity.Chat{}, ErrChatNotFound
+			return entity.ChatMember{}, ErrMemberNotFound
 		}
-		return entity.Chat{}, err
+		return entity.ChatMember{}, err
 	}
 
-	entityChat, err := v1.NewEntityChat(chat)
+	return member, nil
+}
+
+// Members возвращает активных участников чата в порядке вступления
+func (c *ChatRepositoryImpl) Members(ctx context.Context, chatID int64) ([]entity.ChatMember, error) {
+	rows, err := c.db.QueryContext(
+		ctx,
+		"SELECT "+memberColumns+" FROM chat_members WHERE chat_id = ? AND active = 1 ORDER BY joined_at, user_id",
+		chatID,
+	)
 	if err != nil {
-		return entity.Chat{}, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return entityChat, nil
+	var members []entity.ChatMember
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (c *ChatRepositoryImpl) IsMember(ctx context.Context, chatID, userID int64) (bool, error) {
+	var exists bool
+	err := c.db.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM chat_members WHERE chat_id = ? AND user_id = ? AND active = 1)",
+		chatID, userID,
+	).Scan(&exists)
+	return exists, err
 }
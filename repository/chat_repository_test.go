@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+func TestMemberRoles(t *testing.T) {
+	const chatID, userID = -100, 7
+
+	tests := []struct {
+		name     string
+		override entity.MemberRole   // Роль, назначенная командой /role до смены статуса
+		statuses []entity.MemberRole // Роли из Telegram по порядку, первая при вступлении
+		want     entity.MemberRole
+	}{
+		{name: "promote", statuses: []entity.MemberRole{entity.RoleMember, entity.RoleAdmin}, want: entity.RoleAdmin},
+		{name: "demote", statuses: []entity.MemberRole{entity.RoleAdmin, entity.RoleMember}, want: entity.RoleMember},
+		{name: "restricted", statuses: []entity.MemberRole{entity.RoleAdmin, entity.RoleViewer}, want: entity.RoleViewer},
+		{name: "rejoin", statuses: []entity.MemberRole{entity.RoleAdmin, entity.RoleMember, entity.RoleMember}, want: entity.RoleMember},
+		{
+			name: "override survives promote", override: entity.RoleViewer,
+			statuses: []entity.MemberRole{entity.RoleMember, entity.RoleAdmin}, want: entity.RoleViewer,
+		},
+		{
+			name: "override survives demote", override: entity.RoleAdmin,
+			statuses: []entity.MemberRole{entity.RoleAdmin, entity.RoleMember}, want: entity.RoleAdmin,
+		},
+		{
+			name: "owner beats override", override: entity.RoleViewer,
+			statuses: []entity.MemberRole{entity.RoleAdmin, entity.RoleOwner}, want: entity.RoleOwner,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewChatRepositoryImpl(newTestDB(t))
+			if err := repo.Create(ctx, entity.NewChat(chatID)); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, role := range tt.statuses {
+				if err := repo.SaveMember(ctx, entity.NewChatMember(chatID, userID, role)); err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 && tt.override != "" {
+					if err := repo.SetRole(ctx, chatID, userID, tt.override); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			member, err := repo.GetMember(ctx, chatID, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if member.Role != tt.want {
+				t.Errorf("role %s, want %s", member.Role, tt.want)
+			}
+
+			members, err := repo.Members(ctx, chatID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(members) != 1 || members[0].Role != tt.want {
+				t.Errorf("members %+v, want one with role %s", members, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRoleUnknownMember(t *testing.T) {
+	ctx := context.Background()
+	repo := NewChatRepositoryImpl(newTestDB(t))
+	if err := repo.Create(ctx, entity.NewChat(-100)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.SetRole(ctx, -100, 7, entity.RoleAdmin); !errors.Is(err, ErrMemberNotFound) {
+		t.Errorf("SetRole error = %v, want ErrMemberNotFound", err)
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+var ErrChecklistItemNotFound = errors.New("checklist item not found")
+
+type ChecklistRepository interface {
+	Add(ctx context.Context, item *entity.ChecklistItem) error
+	List(ctx context.Context, taskID int64) (entity.Checklist, error)
+	Toggle(ctx context.Context, taskID, itemID, userID int64) (entity.ChecklistItem, error)
+	Remove(ctx context.Context, taskID int64, position int) error
+}
+
+const checklistColumns = "id, task_id, position, text, done, done_by, done_at, created_at"
+
+func scanChecklistItem(row rowScanner) (entity.ChecklistItem, error) {
+	var (
+		item   entity.ChecklistItem
+		doneBy sql.NullInt64
+		doneAt sql.NullTime
+	)
+	err := row.Scan(&item.ID, &item.TaskID, &item.Position, &item.Text, &item.Done, &doneBy, &doneAt, &item.CreatedAt)
+	if err != nil {
+		return entity.ChecklistItem{}, err
+	}
+
+	item.DoneBy = doneBy.Int64
+	item.DoneAt = doneAt.Time
+	return item, nil
+}
+
+// ChecklistRepositoryImpl Репозиторий пунктов чек-листов заданий
+type ChecklistRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewChecklistRepositoryImpl(db *sql.DB) *ChecklistRepositoryImpl {
+	return &ChecklistRepositoryImpl{db: db}
+}
+
+// Add добавляет пункт в конец чек-листа задания
+func (r *ChecklistRepositoryImpl) Add(ctx context.Context, item *entity.ChecklistItem) error {
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO checklist_items (task_id, position, text)
+		VALUES (?, (SELECT COALESCE(MAX(position), 0) + 1 FROM checklist_items WHERE task_id = ?), ?)
+		RETURNING id, position, created_at`,
+		item.TaskID, item.TaskID, item.Text,
+	).Scan(&item.ID, &item.Position, &item.CreatedAt)
+}
+
+func (r *ChecklistRepositoryImpl) List(ctx context.Context, taskID int64) (entity.Checklist, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+checklistColumns+" FROM checklist_items WHERE task_id = ? ORDER BY position",
+		taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checklist entity.Checklist
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		checklist = append(checklist, item)
+	}
+	return checklist, rows.Err()
+}
+
+// Toggle отмечает пункт или снимает с него отметку
+func (r *ChecklistRepositoryImpl) Toggle(ctx context.Context, taskID, itemID, userID int64) (entity.ChecklistItem, error) {
+	item, err := scanChecklistItem(r.db.QueryRowContext(
+		ctx,
+		`UPDATE checklist_items
+		SET done = NOT done,
+			done_by = CASE WHEN done THEN NULL ELSE ? END,
+			done_at = CASE WHEN done THEN NULL ELSE CURRENT_TIMESTAMP END
+		WHERE id = ? AND task_id = ?
+		RETURNING `+checklistColumns,
+		userID, itemID, taskID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ChecklistItem{}, ErrChecklistItemNotFound
+	}
+	return item, err
+}
+
+// Remove удаляет пункт по его номеру и сдвигает номера следующих пунктов
+func (r *ChecklistRepositoryImpl) Remove(ctx context.Context, taskID int64, position int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM checklist_items WHERE task_id = ? AND position = ?", taskID, position)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrChecklistItemNotFound
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE checklist_items SET position = position - 1 WHERE task_id = ? AND position > ?",
+		taskID, position,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+// ErrMessageNotLinked сообщение не относится ни к одному заданию
+var ErrMessageNotLinked = errors.New("message is not linked to a task")
+
+type CommentRepository interface {
+	LinkMessage(ctx context.Context, chatID int64, messageID int, taskID int64) error
+	TaskByMessage(ctx context.Context, chatID int64, messageID int) (int64, error)
+	Add(ctx context.Context, comment *entity.Comment) error
+	List(ctx context.Context, taskID int64, limit int) ([]entity.Comment, error)
+	Count(ctx context.Context, taskID int64) (int, error)
+}
+
+// CommentRepositoryImpl Репозиторий комментариев к заданиям и сообщений бота, по которым они оставляются
+type CommentRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewCommentRepositoryImpl(db *sql.DB) *CommentRepositoryImpl {
+	return &CommentRepositoryImpl{db: db}
+}
+
+// LinkMessage запоминает, что сообщение бота относится к заданию
+func (r *CommentRepositoryImpl) LinkMessage(ctx context.Context, chatID int64, messageID int, taskID int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO task_messages (chat_id, message_id, task_id) VALUES (?, ?, ?)
+		ON CONFLICT (chat_id, message_id) DO UPDATE SET task_id = excluded.task_id`,
+		chatID, messageID, taskID,
+	)
+	return err
+}
+
+// TaskByMessage возвращает ID задания, к которому относится сообщение бота
+func (r *CommentRepositoryImpl) TaskByMessage(ctx context.Context, chatID int64, messageID int) (int64, error) {
+	var taskID int64
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT task_id FROM task_messages WHERE chat_id = ? AND message_id = ?",
+		chatID, messageID,
+	).Scan(&taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrMessageNotLinked
+	}
+	return taskID, err
+}
+
+func (r *CommentRepositoryImpl) Add(ctx context.Context, comment *entity.Comment) error {
+	return r.db.QueryRowContext(
+		ctx,
+		"INSERT INTO task_comments (task_id, chat_id, message_id, user_id, text) VALUES (?, ?, ?, ?, ?) RETURNING id, created_at",
+		comment.TaskID, comment.ChatID, comment.MessageID, comment.UserID, comment.Text,
+	).Scan(&comment.ID, &comment.CreatedAt)
+}
+
+// List возвращает последние limit комментариев задания от старых к новым
+func (r *CommentRepositoryImpl) List(ctx context.Context, taskID int64, limit int) ([]entity.Comment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, task_id, chat_id, message_id, user_id, text, created_at FROM (
+			SELECT * FROM task_comments WHERE task_id = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id`,
+		taskID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []entity.Comment
+	for rows.Next() {
+		var c entity.Comment
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.ChatID, &c.MessageID, &c.UserID, &c.Text, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func (r *CommentRepositoryImpl) Count(ctx context.Context, taskID int64) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM task_comments WHERE task_id = ?", taskID).Scan(&count)
+	return count, err
+}
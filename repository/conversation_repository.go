@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/qrave1/task-track/entity"
+	v1 "github.com/qrave1/task-track/repository/v1"
+)
+
+var ErrConversationNotFound = errors.New("conversation not found")
+
+type ConversationRepository interface {
+	Get(ctx context.Context, chatID, userID int64) (entity.Conversation, error)
+	Save(ctx context.Context, conv entity.Conversation) error
+	Delete(ctx context.Context, chatID, userID int64) error
+}
+
+// ConversationRepositoryImpl Репозиторий для хранения состояния диалогов с пользователями
+type ConversationRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewConversationRepositoryImpl(db *sql.DB) *ConversationRepositoryImpl {
+	return &ConversationRepositoryImpl{db: db}
+}
+
+func (c *ConversationRepositoryImpl) Get(ctx context.Context, chatID, userID int64) (entity.Conversation, error) {
+	var conv v1.Conversation
+	err := c.db.QueryRowContext(
+		ctx,
+		"SELECT chat_id, user_id, state, data FROM conversations WHERE chat_id = ? AND user_id = ?",
+		chatID,
+		userID,
+	).Scan(&conv.ChatID, &conv.UserID, &conv.State, &conv.Data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Conversation{}, ErrConversationNotFound
+		}
+		return entity.Conversation{}, err
+	}
+
+	return v1.NewEntityConversation(conv)
+}
+
+func (c *ConversationRepositoryImpl) Save(ctx context.Context, conv entity.Conversation) error {
+	dbConv, err := v1.NewConversationFromEntity(conv)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.db.ExecContext(
+		ctx,
+		`INSERT INTO conversations (chat_id, user_id, state, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chat_id, user_id) DO UPDATE SET
+			state = excluded.state,
+			data = excluded.data,
+			updated_at = CURRENT_TIMESTAMP`,
+		dbConv.ChatID,
+		dbConv.UserID,
+		dbConv.State,
+		dbConv.Data,
+	)
+	return err
+}
+
+func (c *ConversationRepositoryImpl) Delete(ctx context.Context, chatID, userID int64) error {
+	_, err := c.db.ExecContext(
+		ctx,
+		"DELETE FROM conversations WHERE chat_id = ? AND user_id = ?",
+		chatID,
+		userID,
+	)
+	return err
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+type LedgerRepository interface {
+	Append(ctx context.Context, entry *entity.LedgerEntry) error
+	Balance(ctx context.Context, chatID, userID int64) (int64, error)
+	Leaderboard(ctx context.Context, chatID int64, limit int) ([]entity.Balance, error)
+	History(ctx context.Context, chatID, userID int64, limit int) ([]entity.LedgerEntry, error)
+}
+
+// LedgerRepositoryImpl Репозиторий журнала баланса участников. Записи журнала не изменяются и не удаляются
+type LedgerRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewLedgerRepositoryImpl(db *sql.DB) *LedgerRepositoryImpl {
+	return &LedgerRepositoryImpl{db: db}
+}
+
+func (r *LedgerRepositoryImpl) Append(ctx context.Context, entry *entity.LedgerEntry) error {
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO ledger_entries (chat_id, user_id, amount, kind, task_id, redemption_id, reason, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at`,
+		entry.ChatID, entry.UserID, entry.Amount, entry.Kind, nullID(entry.TaskID), nullID(entry.RedemptionID),
+		entry.Reason, entry.CreatedBy,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (r *LedgerRepositoryImpl) Balance(ctx context.Context, chatID, userID int64) (int64, error) {
+	var balance int64
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE chat_id = ? AND user_id = ?",
+		chatID, userID,
+	).Scan(&balance)
+	return balance, err
+}
+
+// Leaderboard возвращает участников чата с наибольшим балансом
+func (r *LedgerRepositoryImpl) Leaderboard(ctx context.Context, chatID int64, limit int) ([]entity.Balance, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT user_id, SUM(amount) AS balance FROM ledger_entries
+		WHERE chat_id = ?
+		GROUP BY user_id
+		HAVING balance != 0
+		ORDER BY balance DESC, user_id
+		LIMIT ?`,
+		chatID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []entity.Balance
+	for rows.Next() {
+		var balance entity.Balance
+		if err := rows.Scan(&balance.UserID, &balance.Amount); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// History возвращает последние записи журнала участника, начиная с новых
+func (r *LedgerRepositoryImpl) History(ctx context.Context, chatID, userID int64, limit int) ([]entity.LedgerEntry, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, chat_id, user_id, amount, kind, task_id, redemption_id, reason, created_by, created_at FROM ledger_entries
+		WHERE chat_id = ? AND user_id = ?
+		ORDER BY id DESC
+		LIMIT ?`,
+		chatID, userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []entity.LedgerEntry
+	for rows.Next() {
+		var (
+			entry        entity.LedgerEntry
+			taskID       sql.NullInt64
+			redemptionID sql.NullInt64
+		)
+		err := rows.Scan(
+			&entry.ID, &entry.ChatID, &entry.UserID, &entry.Amount, &entry.Kind,
+			&taskID, &redemptionID, &entry.Reason, &entry.CreatedBy, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.TaskID = taskID.Int64
+		entry.RedemptionID = redemptionID.Int64
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
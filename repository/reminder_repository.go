@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ReminderRepository interface {
+	Claim(ctx context.Context, taskID int64, kind string, dueAt time.Time) (bool, error)
+	Release(ctx context.Context, taskID int64, kind string, dueAt time.Time) error
+	Drop(ctx context.Context, taskID int64, kind string, dueAt time.Time) error
+}
+
+// ReminderRepositoryImpl Репозиторий отправленных напоминаний о сроках заданий
+type ReminderRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewReminderRepositoryImpl(db *sql.DB) *ReminderRepositoryImpl {
+	return &ReminderRepositoryImpl{db: db}
+}
+
+// Claim отмечает напоминание как отправленное. Возвращает false, если оно уже было отправлено раньше
+func (r *ReminderRepositoryImpl) Claim(ctx context.Context, taskID int64, kind string, dueAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		"INSERT OR IGNORE INTO task_reminders (task_id, kind, due_at) VALUES (?, ?, ?)",
+		taskID, kind, dueAt.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Release снимает отметку, чтобы напоминание, которое не удалось доставить, отправилось повторно
+func (r *ReminderRepositoryImpl) Release(ctx context.Context, taskID int64, kind string, dueAt time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM task_reminders WHERE task_id = ? AND kind = ? AND due_at = ?",
+		taskID, kind, dueAt.UTC(),
+	)
+	return err
+}
+
+// Drop отмечает напоминание, которое невозможно доставить. Отметка не снимается, поэтому повторно оно не отправляется
+func (r *ReminderRepositoryImpl) Drop(ctx context.Context, taskID int64, kind string, dueAt time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE task_reminders SET dropped = 1 WHERE task_id = ? AND kind = ? AND due_at = ?",
+		taskID, kind, dueAt.UTC(),
+	)
+	return err
+}
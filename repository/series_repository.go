@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+var (
+	ErrSeriesNotFound = errors.New("task series not found")
+	// ErrSeriesConflict следующее задание серии уже создано другим обработчиком
+	ErrSeriesConflict = errors.New("task series was advanced concurrently")
+)
+
+type SeriesRepository interface {
+	Create(ctx context.Context, series *entity.Series) error
+	GetByID(ctx context.Context, chatID, id int64) (entity.Series, error)
+	List(ctx context.Context, chatID int64) ([]entity.Series, error)
+	ListActive(ctx context.Context) ([]entity.Series, error)
+	SetStatus(ctx context.Context, chatID, id int64, status entity.SeriesStatus) error
+	Advance(ctx context.Context, id, fromTaskID, toTaskID int64) error
+}
+
+const seriesColumns = "id, chat_id, rule, title, description, reward, reward_amount, assignee_id, start_at, status, last_task_id, created_by, created_at"
+
+func scanSeries(row rowScanner) (entity.Series, error) {
+	var (
+		series     entity.Series
+		rule       string
+		assigneeID sql.NullInt64
+		lastTaskID sql.NullInt64
+	)
+	err := row.Scan(
+		&series.ID, &series.ChatID, &rule, &series.Title, &series.Description, &series.Reward,
+		&series.RewardAmount, &assigneeID, &series.StartAt, &series.Status, &lastTaskID, &series.CreatedBy, &series.CreatedAt,
+	)
+	if err != nil {
+		return entity.Series{}, err
+	}
+
+	series.Rule, err = entity.ParseRecurrence(rule)
+	if err != nil {
+		return entity.Series{}, fmt.Errorf("series %d: %w", series.ID, err)
+	}
+
+	series.AssigneeID = assigneeID.Int64
+	series.LastTaskID = lastTaskID.Int64
+	return series, nil
+}
+
+// SeriesRepositoryImpl Репозиторий серий повторяющихся заданий
+type SeriesRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewSeriesRepositoryImpl(db *sql.DB) *SeriesRepositoryImpl {
+	return &SeriesRepositoryImpl{db: db}
+}
+
+// Create сохраняет серию и привязывает к ней её последнее задание
+func (r *SeriesRepositoryImpl) Create(ctx context.Context, series *entity.Series) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO task_series (chat_id, rule, title, description, reward, reward_amount, assignee_id, start_at, status, last_task_id, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at`,
+		series.ChatID, series.Rule.String(), series.Title, series.Description, series.Reward, series.RewardAmount,
+		nullID(series.AssigneeID),
+		series.StartAt.UTC(), series.Status, nullID(series.LastTaskID), series.CreatedBy,
+	).Scan(&series.ID, &series.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if series.LastTaskID != 0 {
+		_, err = tx.ExecContext(ctx, "UPDATE tasks SET series_id = ?, version = version + 1 WHERE id = ?", series.ID, series.LastTaskID)
+		if err != nil {
+			return fmt.Errorf("link task to series: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SeriesRepositoryImpl) GetByID(ctx context.Context, chatID, id int64) (entity.Series, error) {
+	series, err := scanSeries(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+seriesColumns+" FROM task_series WHERE id = ? AND chat_id = ?",
+		id, chatID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Series{}, ErrSeriesNotFound
+		}
+		return entity.Series{}, err
+	}
+	return series, nil
+}
+
+// List возвращает незавершённые серии чата
+func (r *SeriesRepositoryImpl) List(ctx context.Context, chatID int64) ([]entity.Series, error) {
+	return r.query(
+		ctx,
+		"SELECT "+seriesColumns+" FROM task_series WHERE chat_id = ? AND status != ? ORDER BY id",
+		chatID, entity.SeriesEnded,
+	)
+}
+
+// ListActive возвращает активные серии всех чатов
+func (r *SeriesRepositoryImpl) ListActive(ctx context.Context) ([]entity.Series, error) {
+	return r.query(
+		ctx,
+		"SELECT "+seriesColumns+" FROM task_series WHERE status = ? ORDER BY id",
+		entity.SeriesActive,
+	)
+}
+
+func (r *SeriesRepositoryImpl) query(ctx context.Context, query string, args ...any) ([]entity.Series, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []entity.Series
+	for rows.Next() {
+		series, err := scanSeries(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, series)
+	}
+	return list, rows.Err()
+}
+
+func (r *SeriesRepositoryImpl) SetStatus(ctx context.Context, chatID, id int64, status entity.SeriesStatus) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE task_series SET status = ? WHERE id = ? AND chat_id = ?",
+		status, id, chatID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSeriesNotFound
+	}
+	return nil
+}
+
+// Advance делает toTaskID последним заданием серии. Если последним уже стало другое задание,
+// возвращает ErrSeriesConflict
+func (r *SeriesRepositoryImpl) Advance(ctx context.Context, id, fromTaskID, toTaskID int64) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE task_series SET last_task_id = ? WHERE id = ? AND last_task_id IS ?",
+		toTaskID, id, nullID(fromTaskID),
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSeriesConflict
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+var (
+	ErrPrizeNotFound      = errors.New("prize not found")
+	ErrOutOfStock         = errors.New("prize is out of stock")
+	ErrInsufficientFunds  = errors.New("insufficient balance")
+	ErrRedemptionNotFound = errors.New("redemption not found")
+	// ErrRedemptionResolved покупку уже выдали или вернули
+	ErrRedemptionResolved = errors.New("redemption is already resolved")
+)
+
+type ShopRepository interface {
+	CreatePrize(ctx context.Context, prize *entity.Prize) error
+	GetPrize(ctx context.Context, chatID, id int64) (entity.Prize, error)
+	ListPrizes(ctx context.Context, chatID int64) ([]entity.Prize, error)
+	DisablePrize(ctx context.Context, chatID, id int64) error
+
+	Redeem(ctx context.Context, chatID, prizeID, userID int64) (entity.Redemption, error)
+	GetRedemption(ctx context.Context, chatID, id int64) (entity.Redemption, error)
+	PendingRedemptions(ctx context.Context, chatID int64) ([]entity.Redemption, error)
+	Fulfill(ctx context.Context, chatID, id, adminID int64) (entity.Redemption, error)
+	Refund(ctx context.Context, chatID, id, adminID int64) (entity.Redemption, error)
+}
+
+const (
+	prizeColumns      = "id, chat_id, title, price, stock, active, created_by, created_at"
+	redemptionColumns = "id, chat_id, prize_id, user_id, title, price, status, resolved_by, resolved_at, created_at"
+)
+
+func scanPrize(row rowScanner) (entity.Prize, error) {
+	var (
+		prize entity.Prize
+		stock sql.NullInt64
+	)
+	err := row.Scan(&prize.ID, &prize.ChatID, &prize.Title, &prize.Price, &stock, &prize.Active, &prize.CreatedBy, &prize.CreatedAt)
+	if err != nil {
+		return entity.Prize{}, err
+	}
+
+	prize.Stock = entity.UnlimitedStock
+	if stock.Valid {
+		prize.Stock = stock.Int64
+	}
+	return prize, nil
+}
+
+func scanRedemption(row rowScanner) (entity.Redemption, error) {
+	var (
+		redemption entity.Redemption
+		resolvedBy sql.NullInt64
+		resolvedAt sql.NullTime
+	)
+	err := row.Scan(
+		&redemption.ID, &redemption.ChatID, &redemption.PrizeID, &redemption.UserID, &redemption.Title,
+		&redemption.Price, &redemption.Status, &resolvedBy, &resolvedAt, &redemption.CreatedAt,
+	)
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+
+	redemption.ResolvedBy = resolvedBy.Int64
+	redemption.ResolvedAt = resolvedAt.Time
+	return redemption, nil
+}
+
+// nullStock преобразует неограниченный запас в NULL
+func nullStock(stock int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: stock, Valid: stock != entity.UnlimitedStock}
+}
+
+// ShopRepositoryImpl Репозиторий каталога призов и их покупок
+type ShopRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewShopRepositoryImpl(db *sql.DB) *ShopRepositoryImpl {
+	return &ShopRepositoryImpl{db: db}
+}
+
+func (r *ShopRepositoryImpl) CreatePrize(ctx context.Context, prize *entity.Prize) error {
+	prize.Active = true
+	return r.db.QueryRowContext(
+		ctx,
+		"INSERT INTO prizes (chat_id, title, price, stock, created_by) VALUES (?, ?, ?, ?, ?) RETURNING id, created_at",
+		prize.ChatID, prize.Title, prize.Price, nullStock(prize.Stock), prize.CreatedBy,
+	).Scan(&prize.ID, &prize.CreatedAt)
+}
+
+func (r *ShopRepositoryImpl) GetPrize(ctx context.Context, chatID, id int64) (entity.Prize, error) {
+	prize, err := scanPrize(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+prizeColumns+" FROM prizes WHERE id = ? AND chat_id = ?",
+		id, chatID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Prize{}, ErrPrizeNotFound
+	}
+	return prize, err
+}
+
+// ListPrizes возвращает призы из каталога чата, включая закончившиеся
+func (r *ShopRepositoryImpl) ListPrizes(ctx context.Context, chatID int64) ([]entity.Prize, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+prizeColumns+" FROM prizes WHERE chat_id = ? AND active = 1 ORDER BY price, id",
+		chatID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prizes []entity.Prize
+	for rows.Next() {
+		prize, err := scanPrize(rows)
+		if err != nil {
+			return nil, err
+		}
+		prizes = append(prizes, prize)
+	}
+	return prizes, rows.Err()
+}
+
+// DisablePrize убирает приз из каталога. Уже совершённые покупки сохраняются
+func (r *ShopRepositoryImpl) DisablePrize(ctx context.Context, chatID, id int64) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE prizes SET active = 0 WHERE id = ? AND chat_id = ? AND active = 1", id, chatID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPrizeNotFound
+	}
+	return nil
+}
+
+// Redeem покупает приз: в одной транзакции проверяет баланс, уменьшает запас и списывает цену
+func (r *ShopRepositoryImpl) Redeem(ctx context.Context, chatID, prizeID, userID int64) (entity.Redemption, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+	defer tx.Rollback()
+
+	prize, err := scanPrize(tx.QueryRowContext(
+		ctx,
+		"SELECT "+prizeColumns+" FROM prizes WHERE id = ? AND chat_id = ? AND active = 1",
+		prizeID, chatID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Redemption{}, ErrPrizeNotFound
+	}
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+	if !prize.InStock() {
+		return entity.Redemption{}, ErrOutOfStock
+	}
+
+	var balance int64
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE chat_id = ? AND user_id = ?",
+		chatID, userID,
+	).Scan(&balance)
+	if err != nil {
+		return entity.Redemption{}, fmt.Errorf("get balance: %w", err)
+	}
+	if balance < prize.Price {
+		return entity.Redemption{}, ErrInsufficientFunds
+	}
+
+	if prize.Stock != entity.UnlimitedStock {
+		res, err := tx.ExecContext(ctx, "UPDATE prizes SET stock = stock - 1 WHERE id = ? AND stock > 0", prize.ID)
+		if err != nil {
+			return entity.Redemption{}, fmt.Errorf("decrement stock: %w", err)
+		}
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			return entity.Redemption{}, ErrOutOfStock
+		}
+	}
+
+	redemption := entity.Redemption{
+		ChatID:  chatID,
+		PrizeID: prize.ID,
+		UserID:  userID,
+		Title:   prize.Title,
+		Price:   prize.Price,
+		Status:  entity.RedemptionPending,
+	}
+	err = tx.QueryRowContext(
+		ctx,
+		"INSERT INTO redemptions (chat_id, prize_id, user_id, title, price, status) VALUES (?, ?, ?, ?, ?, ?) RETURNING id, created_at",
+		redemption.ChatID, redemption.PrizeID, redemption.UserID, redemption.Title, redemption.Price, redemption.Status,
+	).Scan(&redemption.ID, &redemption.CreatedAt)
+	if err != nil {
+		return entity.Redemption{}, fmt.Errorf("insert redemption: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO ledger_entries (chat_id, user_id, amount, kind, redemption_id, reason, created_by) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		chatID, userID, -prize.Price, entity.LedgerPurchase, redemption.ID, prize.Title, userID,
+	)
+	if err != nil {
+		return entity.Redemption{}, fmt.Errorf("debit balance: %w", err)
+	}
+
+	return redemption, tx.Commit()
+}
+
+func (r *ShopRepositoryImpl) GetRedemption(ctx context.Context, chatID, id int64) (entity.Redemption, error) {
+	redemption, err := scanRedemption(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+redemptionColumns+" FROM redemptions WHERE id = ? AND chat_id = ?",
+		id, chatID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Redemption{}, ErrRedemptionNotFound
+	}
+	return redemption, err
+}
+
+func (r *ShopRepositoryImpl) PendingRedemptions(ctx context.Context, chatID int64) ([]entity.Redemption, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+redemptionColumns+" FROM redemptions WHERE chat_id = ? AND status = ? ORDER BY id",
+		chatID, entity.RedemptionPending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []entity.Redemption
+	for rows.Next() {
+		redemption, err := scanRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, redemption)
+	}
+	return list, rows.Err()
+}
+
+// Fulfill отмечает, что администратор выдал приз
+func (r *ShopRepositoryImpl) Fulfill(ctx context.Context, chatID, id, adminID int64) (entity.Redemption, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+	defer tx.Rollback()
+
+	redemption, err := r.resolve(ctx, tx, chatID, id, adminID, entity.RedemptionFulfilled)
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+	return redemption, tx.Commit()
+}
+
+// Refund отменяет покупку: возвращает сумму на баланс и приз в запас
+func (r *ShopRepositoryImpl) Refund(ctx context.Context, chatID, id, adminID int64) (entity.Redemption, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+	defer tx.Rollback()
+
+	redemption, err := r.resolve(ctx, tx, chatID, id, adminID, entity.RedemptionRefunded)
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO ledger_entries (chat_id, user_id, amount, kind, redemption_id, reason, created_by) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		chatID, redemption.UserID, redemption.Price, entity.LedgerRefund, redemption.ID, redemption.Title, adminID,
+	)
+	if err != nil {
+		return entity.Redemption{}, fmt.Errorf("refund balance: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE prizes SET stock = stock + 1 WHERE id = ? AND stock IS NOT NULL", redemption.PrizeID)
+	if err != nil {
+		return entity.Redemption{}, fmt.Errorf("restore stock: %w", err)
+	}
+
+	return redemption, tx.Commit()
+}
+
+// resolve переводит ожидающую покупку в конечный статус
+func (r *ShopRepositoryImpl) resolve(
+	ctx context.Context,
+	tx *sql.Tx,
+	chatID, id, adminID int64,
+	status entity.RedemptionStatus,
+) (entity.Redemption, error) {
+	redemption, err := scanRedemption(tx.QueryRowContext(
+		ctx,
+		`UPDATE redemptions SET status = ?, resolved_by = ?, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND chat_id = ? AND status = ?
+		RETURNING `+redemptionColumns,
+		status, adminID, id, chatID, entity.RedemptionPending,
+	))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return redemption, err
+	}
+
+	// Отличаем уже обработанную покупку от несуществующей
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM redemptions WHERE id = ? AND chat_id = ?)", id, chatID).Scan(&exists)
+	if err != nil {
+		return entity.Redemption{}, err
+	}
+	if exists {
+		return entity.Redemption{}, ErrRedemptionResolved
+	}
+	return entity.Redemption{}, ErrRedemptionNotFound
+}
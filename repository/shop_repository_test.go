@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+const (
+	shopChatID = -1
+	shopBuyer  = 7
+	shopAdmin  = 9
+)
+
+// newShop создаёт приз и начисляет покупателю balance
+func newShop(t *testing.T, db *sql.DB, price, stock, balance int64) (*ShopRepositoryImpl, *LedgerRepositoryImpl, entity.Prize) {
+	t.Helper()
+
+	ctx := context.Background()
+	shop := NewShopRepositoryImpl(db)
+	ledger := NewLedgerRepositoryImpl(db)
+
+	prize := entity.Prize{ChatID: shopChatID, Title: "Шоколадка", Price: price, Stock: stock, CreatedBy: shopAdmin}
+	if err := shop.CreatePrize(ctx, &prize); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := &entity.LedgerEntry{ChatID: shopChatID, UserID: shopBuyer, Amount: balance, Kind: entity.LedgerAdjustment, CreatedBy: shopAdmin}
+	if err := ledger.Append(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	return shop, ledger, prize
+}
+
+// balanceOf баланс покупателя
+func balanceOf(t *testing.T, ledger *LedgerRepositoryImpl) int64 {
+	t.Helper()
+
+	balance, err := ledger.Balance(context.Background(), shopChatID, shopBuyer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func TestRedeemInsufficientBalance(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	shop, ledger, prize := newShop(t, db, 100, 3, 99)
+
+	if _, err := shop.Redeem(ctx, shopChatID, prize.ID, shopBuyer); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Redeem error = %v, want ErrInsufficientFunds", err)
+	}
+
+	// Неудачная покупка ничего не списывает и не трогает запас
+	if balance := balanceOf(t, ledger); balance != 99 {
+		t.Errorf("balance %d, want 99", balance)
+	}
+	got, err := shop.GetPrize(ctx, shopChatID, prize.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stock != 3 {
+		t.Errorf("stock %d, want 3", got.Stock)
+	}
+	if pending, err := shop.PendingRedemptions(ctx, shopChatID); err != nil || len(pending) != 0 {
+		t.Errorf("pending redemptions %v, %v", pending, err)
+	}
+}
+
+func TestConcurrentRedeemsDoNotOverdraw(t *testing.T) {
+	tests := []struct {
+		name    string
+		stock   int64
+		balance int64
+		want    int
+		wantErr error
+	}{
+		{"balance runs out", entity.UnlimitedStock, 250, 2, ErrInsufficientFunds},
+		{"stock runs out", 3, 1000, 3, ErrOutOfStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			shop, ledger, prize := newShop(t, db, 100, tt.stock, tt.balance)
+
+			const attempts = 10
+			var (
+				wg     sync.WaitGroup
+				mu     sync.Mutex
+				bought int
+			)
+			for range attempts {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					_, err := shop.Redeem(ctx, shopChatID, prize.ID, shopBuyer)
+					if err != nil && !errors.Is(err, tt.wantErr) {
+						t.Errorf("Redeem error = %v", err)
+						return
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+					if err == nil {
+						bought++
+					}
+				}()
+			}
+			wg.Wait()
+
+			if bought != tt.want {
+				t.Errorf("%d redemptions succeeded, want %d", bought, tt.want)
+			}
+			if balance := balanceOf(t, ledger); balance != tt.balance-int64(tt.want)*prize.Price {
+				t.Errorf("balance %d, want %d", balance, tt.balance-int64(tt.want)*prize.Price)
+			}
+			if pending, err := shop.PendingRedemptions(ctx, shopChatID); err != nil || len(pending) != tt.want {
+				t.Errorf("%d pending redemptions, %v, want %d", len(pending), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefundRestoresBalance(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	shop, ledger, prize := newShop(t, db, 100, 1, 150)
+
+	redemption, err := shop.Redeem(ctx, shopChatID, prize.ID, shopBuyer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := balanceOf(t, ledger); balance != 50 {
+		t.Fatalf("balance after purchase %d, want 50", balance)
+	}
+
+	refunded, err := shop.Refund(ctx, shopChatID, redemption.ID, shopAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refunded.Status != entity.RedemptionRefunded || refunded.ResolvedBy != shopAdmin {
+		t.Errorf("refunded redemption %+v", refunded)
+	}
+
+	// Возврат записывается в журнал отдельной записью, а приз возвращается в запас
+	if balance := balanceOf(t, ledger); balance != 150 {
+		t.Errorf("balance after refund %d, want 150", balance)
+	}
+	history, err := ledger.History(ctx, shopChatID, shopBuyer, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refunds int
+	for _, entry := range history {
+		if entry.Kind == entity.LedgerRefund {
+			refunds++
+			if entry.Amount != prize.Price || entry.RedemptionID != redemption.ID || entry.CreatedBy != shopAdmin {
+				t.Errorf("refund entry %+v", entry)
+			}
+		}
+	}
+	if refunds != 1 {
+		t.Errorf("%d refund entries, want 1", refunds)
+	}
+	if got, err := shop.GetPrize(ctx, shopChatID, prize.ID); err != nil || got.Stock != 1 {
+		t.Errorf("stock after refund %d, %v, want 1", got.Stock, err)
+	}
+
+	// Повторный возврат не начисляет сумму второй раз
+	if _, err := shop.Refund(ctx, shopChatID, redemption.ID, shopAdmin); !errors.Is(err, ErrRedemptionResolved) {
+		t.Errorf("second Refund error = %v, want ErrRedemptionResolved", err)
+	}
+	if balance := balanceOf(t, ledger); balance != 150 {
+		t.Errorf("balance after second refund %d, want 150", balance)
+	}
+	if _, err := shop.Refund(ctx, shopChatID, redemption.ID+1, shopAdmin); !errors.Is(err, ErrRedemptionNotFound) {
+		t.Errorf("Refund of unknown redemption error = %v, want ErrRedemptionNotFound", err)
+	}
+}
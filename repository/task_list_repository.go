@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrTaskListNotFound = errors.New("task list not found")
+
+type TaskListRepository interface {
+	Save(ctx context.Context, list TaskList) error
+	Get(ctx context.Context, chatID int64, messageID int) (TaskList, error)
+}
+
+// TaskList Список заданий, показанный в сообщении
+type TaskList struct {
+	ChatID    int64      `json:"-"`
+	MessageID int        `json:"-"`
+	Filter    TaskFilter `json:"filter"`  // Условия выборки, курсор в нём не используется
+	Cursors   []string   `json:"cursors"` // Курсоры открытых страниц по порядку, у первой страницы курсор пустой
+	Next      string     `json:"next"`    // Курсор следующей страницы, пустой на последней
+}
+
+// NewTaskList список, открытый на первой странице
+func NewTaskList(chatID int64, filter TaskFilter) TaskList {
+	return TaskList{ChatID: chatID, Filter: filter, Cursors: []string{""}}
+}
+
+// Cursor курсор текущей страницы
+func (l TaskList) Cursor() string {
+	if len(l.Cursors) == 0 {
+		return ""
+	}
+	return l.Cursors[len(l.Cursors)-1]
+}
+
+// TaskListRepositoryImpl Репозиторий состояния сообщений со списком заданий
+type TaskListRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewTaskListRepositoryImpl(db *sql.DB) *TaskListRepositoryImpl {
+	return &TaskListRepositoryImpl{db: db}
+}
+
+func (r *TaskListRepositoryImpl) Save(ctx context.Context, list TaskList) error {
+	state, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("marshal task list: %w", err)
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO task_lists (chat_id, message_id, state) VALUES (?, ?, ?)
+		ON CONFLICT (chat_id, message_id) DO UPDATE SET state = excluded.state, updated_at = CURRENT_TIMESTAMP`,
+		list.ChatID, list.MessageID, state,
+	)
+	return err
+}
+
+func (r *TaskListRepositoryImpl) Get(ctx context.Context, chatID int64, messageID int) (TaskList, error) {
+	var state []byte
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT state FROM task_lists WHERE chat_id = ? AND message_id = ?",
+		chatID, messageID,
+	).Scan(&state)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TaskList{}, ErrTaskListNotFound
+		}
+		return TaskList{}, err
+	}
+
+	list := TaskList{ChatID: chatID, MessageID: messageID}
+	if err := json.Unmarshal(state, &list); err != nil {
+		return TaskList{}, fmt.Errorf("unmarshal task list: %w", err)
+	}
+	return list, nil
+}
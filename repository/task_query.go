@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+// ErrInvalidCursor курсор повреждён или выдан для другой сортировки
+var ErrInvalidCursor = errors.New("invalid task cursor")
+
+// TaskSort Порядок выдачи заданий
+type TaskSort string
+
+const (
+	SortCreatedDesc TaskSort = "created_desc" // Сначала новые, порядок по умолчанию
+	SortCreatedAsc  TaskSort = "created_asc"  // Сначала старые
+	SortDueAsc      TaskSort = "due_asc"      // Сначала ближайший срок, задания без срока в конце
+)
+
+// Размер страницы по умолчанию и наибольший допустимый
+const (
+	defaultTaskPageSize = 20
+	maxTaskPageSize     = 100
+)
+
+// createdLayout Формат, в котором SQLite хранит CURRENT_TIMESTAMP в created_at
+const createdLayout = "2006-01-02 15:04:05"
+
+// TaskFilter Условия выборки заданий. Нулевые поля не ограничивают выборку, ChatID обязателен
+type TaskFilter struct {
+	ChatID      int64
+	Statuses    []entity.TaskStatus
+	AssigneeID  int64
+	CreatedBy   int64
+	CreatedFrom time.Time // Включительно
+	CreatedTo   time.Time // Не включительно
+	DueFrom     time.Time // Включительно, задания без срока не попадают в выборку
+	DueTo       time.Time // Не включительно, задания без срока не попадают в выборку
+	Search      string    // Слова, с которых начинаются слова в названии или описании
+	Sort        TaskSort
+	Limit       int    // Размер страницы, по умолчанию 20, не больше 100
+	Cursor      string // Курсор из TaskPage.NextCursor для следующей страницы
+}
+
+// TaskPage Страница заданий. NextCursor пустой на последней странице
+type TaskPage struct {
+	Tasks      []*entity.Task
+	NextCursor string
+}
+
+// taskCursor Ключ последнего задания страницы
+type taskCursor struct {
+	Sort   TaskSort  `json:"s"`
+	ID     int64     `json:"i"`
+	Time   time.Time `json:"t"`
+	NoTime bool      `json:"n,omitempty"` // У задания нет срока, для сортировки по сроку
+}
+
+func encodeCursor(c taskCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string, sort TaskSort) (taskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return taskCursor{}, ErrInvalidCursor
+	}
+
+	var c taskCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort || c.ID <= 0 {
+		return taskCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// searchQuery превращает слова поиска в запрос FTS5: каждое слово в кавычках с поиском по началу
+func searchQuery(search string) string {
+	words := strings.Fields(search)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"*`
+	}
+	return strings.Join(words, " ")
+}
+
+// Query возвращает страницу заданий, подходящих под фильтр, и курсор следующей страницы
+func (r *TaskRepositoryImpl) Query(ctx context.Context, filter TaskFilter) (TaskPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = SortCreatedDesc
+	}
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = defaultTaskPageSize
+	case filter.Limit > maxTaskPageSize:
+		filter.Limit = maxTaskPageSize
+	}
+
+	where := []string{"chat_id = ?"}
+	args := []any{filter.ChatID}
+
+	if len(filter.Statuses) > 0 {
+		where = append(where, "status IN (?"+strings.Repeat(", ?", len(filter.Statuses)-1)+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.AssigneeID != 0 {
+		where = append(where, "assignee_id = ?")
+		args = append(args, filter.AssigneeID)
+	}
+	if filter.CreatedBy != 0 {
+		where = append(where, "created_by = ?")
+		args = append(args, filter.CreatedBy)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.CreatedFrom.UTC().Format(createdLayout))
+	}
+	if !filter.CreatedTo.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.CreatedTo.UTC().Format(createdLayout))
+	}
+	if !filter.DueFrom.IsZero() {
+		where = append(where, "due_at >= ?")
+		args = append(args, filter.DueFrom.UTC())
+	}
+	if !filter.DueTo.IsZero() {
+		where = append(where, "due_at < ?")
+		args = append(args, filter.DueTo.UTC())
+	}
+	if query := searchQuery(filter.Search); query != "" {
+		where = append(where, "id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)")
+		args = append(args, query)
+	}
+
+	var order string
+	switch filter.Sort {
+	case SortCreatedDesc:
+		order = "created_at DESC, id DESC"
+	case SortCreatedAsc:
+		order = "created_at, id"
+	case SortDueAsc:
+		order = "due_at IS NULL, due_at, id"
+	default:
+		return TaskPage{}, fmt.Errorf("unknown task sort %q", filter.Sort)
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return TaskPage{}, err
+		}
+
+		cond, condArgs := cursorCondition(cursor)
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+
+	// Лишняя строка показывает, есть ли следующая страница
+	args = append(args, filter.Limit+1)
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+taskColumns+" FROM tasks WHERE "+strings.Join(where, " AND ")+" ORDER BY "+order+" LIMIT ?",
+		args...,
+	)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	defer rows.Close()
+
+	var page TaskPage
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return TaskPage{}, err
+		}
+		page.Tasks = append(page.Tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return TaskPage{}, err
+	}
+
+	if len(page.Tasks) > filter.Limit {
+		page.Tasks = page.Tasks[:filter.Limit]
+		page.NextCursor = encodeCursor(newTaskCursor(page.Tasks[len(page.Tasks)-1], filter.Sort))
+	}
+	return page, nil
+}
+
+func newTaskCursor(task *entity.Task, sort TaskSort) taskCursor {
+	cursor := taskCursor{Sort: sort, ID: task.ID, Time: task.CreatedAt}
+	if sort == SortDueAsc {
+		cursor.Time = task.DueAt
+		cursor.NoTime = !task.HasDeadline()
+	}
+	return cursor
+}
+
+// cursorCondition условие на задания после курсора в порядке его сортировки
+func cursorCondition(c taskCursor) (string, []any) {
+	switch c.Sort {
+	case SortCreatedAsc:
+		created := c.Time.UTC().Format(createdLayout)
+		return "(created_at > ? OR (created_at = ? AND id > ?))", []any{created, created, c.ID}
+	case SortDueAsc:
+		if c.NoTime {
+			return "(due_at IS NULL AND id > ?)", []any{c.ID}
+		}
+		due := c.Time.UTC()
+		return "(due_at IS NULL OR due_at > ? OR (due_at = ? AND id > ?))", []any{due, due, c.ID}
+	default:
+		created := c.Time.UTC().Format(createdLayout)
+		return "(created_at < ? OR (created_at = ? AND id < ?))", []any{created, created, c.ID}
+	}
+}
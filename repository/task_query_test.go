@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+const queryChatID = -1
+
+// createTasks создаёт задания с названиями titles и возвращает их по порядку
+func createTasks(t *testing.T, repo *TaskRepositoryImpl, titles ...string) []*entity.Task {
+	t.Helper()
+
+	tasks := make([]*entity.Task, 0, len(titles))
+	for _, title := range titles {
+		task := &entity.Task{ChatID: queryChatID, Title: title, CreatedBy: 7}
+		if err := repo.Create(context.Background(), task); err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// queryIDs проходит все страницы выборки по limit заданий и возвращает ID в порядке выдачи
+func queryIDs(t *testing.T, repo *TaskRepositoryImpl, filter TaskFilter, limit int) []int64 {
+	t.Helper()
+
+	filter.ChatID = queryChatID
+	filter.Limit = limit
+
+	var ids []int64
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("query does not reach the last page")
+		}
+
+		page, err := repo.Query(context.Background(), filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Tasks) > limit {
+			t.Fatalf("page of %d tasks, limit %d", len(page.Tasks), limit)
+		}
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+
+		if page.NextCursor == "" {
+			return ids
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func TestQueryPagesThroughEqualCreatedAt(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewTaskRepositoryImpl(db)
+
+	tasks := createTasks(t, repo, "Первое", "Второе", "Третье", "Четвёртое", "Пятое")
+
+	// Задания, созданные в одну секунду, различаются только ID, границы страниц проходят внутри этой группы
+	if _, err := db.Exec("UPDATE tasks SET created_at = '2026-03-01 10:00:00'"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE tasks SET created_at = '2026-03-01 09:00:00' WHERE id = ?", tasks[4].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	desc := []int64{tasks[3].ID, tasks[2].ID, tasks[1].ID, tasks[0].ID, tasks[4].ID}
+	asc := []int64{tasks[4].ID, tasks[0].ID, tasks[1].ID, tasks[2].ID, tasks[3].ID}
+
+	for _, limit := range []int{1, 2, 3, 5} {
+		if got := queryIDs(t, repo, TaskFilter{}, limit); !slices.Equal(got, desc) {
+			t.Errorf("created_desc by %d = %v, want %v", limit, got, desc)
+		}
+		if got := queryIDs(t, repo, TaskFilter{Sort: SortCreatedAsc}, limit); !slices.Equal(got, asc) {
+			t.Errorf("created_asc by %d = %v, want %v", limit, got, asc)
+		}
+	}
+}
+
+func TestQueryDueAscPutsTasksWithoutDueLast(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTaskRepositoryImpl(newTestDB(t))
+
+	early := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	late := early.Add(24 * time.Hour)
+
+	tasks := createTasks(t, repo, "Без срока", "Поздний", "Без срока 2", "Ранний", "Поздний 2", "Без срока 3")
+	for i, due := range map[int]time.Time{1: late, 3: early, 4: late} {
+		tasks[i].DueAt = due
+		if err := repo.Update(ctx, tasks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []int64{tasks[3].ID, tasks[1].ID, tasks[4].ID, tasks[0].ID, tasks[2].ID, tasks[5].ID}
+	for _, limit := range []int{1, 2, 4} {
+		if got := queryIDs(t, repo, TaskFilter{Sort: SortDueAsc}, limit); !slices.Equal(got, want) {
+			t.Errorf("due_asc by %d = %v, want %v", limit, got, want)
+		}
+	}
+
+	// Фильтр по сроку отбрасывает задания без срока
+	got := queryIDs(t, repo, TaskFilter{Sort: SortDueAsc, DueFrom: early, DueTo: late}, 2)
+	if !slices.Equal(got, []int64{tasks[3].ID}) {
+		t.Errorf("due range = %v, want only the early task", got)
+	}
+}
+
+func TestQueryRejectsInvalidCursor(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTaskRepositoryImpl(newTestDB(t))
+
+	createTasks(t, repo, "Первое", "Второе", "Третье")
+
+	page, err := repo.Query(ctx, TaskFilter{ChatID: queryChatID, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("no cursor for the second page")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(page.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte(nil), raw...)
+	tampered[len(tampered)-1] = '!'
+
+	tests := []struct {
+		name   string
+		sort   TaskSort
+		cursor string
+	}{
+		{"not base64", SortCreatedDesc, "%%%"},
+		{"not json", SortCreatedDesc, base64.RawURLEncoding.EncodeToString([]byte("page 2"))},
+		{"tampered json", SortCreatedDesc, base64.RawURLEncoding.EncodeToString(tampered)},
+		{"other sort", SortDueAsc, page.NextCursor},
+		{"unknown sort", SortCreatedDesc, encodeCursor(taskCursor{Sort: "random", ID: 1})},
+		{"zero id", SortCreatedDesc, encodeCursor(taskCursor{Sort: SortCreatedDesc})},
+		{"negative id", SortCreatedDesc, encodeCursor(taskCursor{Sort: SortCreatedDesc, ID: -5})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.Query(ctx, TaskFilter{ChatID: queryChatID, Sort: tt.sort, Cursor: tt.cursor})
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Query error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestQuerySearchFollowsTaskChanges(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTaskRepositoryImpl(newTestDB(t))
+
+	tasks := createTasks(t, repo, "Купить молоко", "Помыть машину", `Прочитать "Войну и мир"`)
+	tasks[1].Description = "Во дворе у магазина"
+	if err := repo.Update(ctx, tasks[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(text string) []int64 {
+		t.Helper()
+		return queryIDs(t, repo, TaskFilter{Search: text}, 10)
+	}
+
+	tests := []struct {
+		search string
+		want   []int64
+	}{
+		{"молоко", []int64{tasks[0].ID}},
+		{"МОЛОК", []int64{tasks[0].ID}},  // Без учёта регистра и по началу слова
+		{"магаз", []int64{tasks[1].ID}},  // По описанию
+		{"купить машину", nil},           // Все слова должны найтись в одном задании
+		{`"войну`, []int64{tasks[2].ID}}, // Кавычки не ломают запрос FTS5
+		{"OR молоко", nil},               // Операторы FTS5 ищутся как слова
+		{"  ", []int64{tasks[2].ID, tasks[1].ID, tasks[0].ID}},
+	}
+	for _, tt := range tests {
+		if got := search(tt.search); !slices.Equal(got, tt.want) {
+			t.Errorf("search %q = %v, want %v", tt.search, got, tt.want)
+		}
+	}
+
+	// Изменение названия переиндексируется триггером
+	tasks[0].Title = "Купить хлеб"
+	if err := repo.Update(ctx, tasks[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("молоко"); len(got) != 0 {
+		t.Errorf("search by the old title = %v, want none", got)
+	}
+	if got := search("хлеб"); !slices.Equal(got, []int64{tasks[0].ID}) {
+		t.Errorf("search by the new title = %v, want %v", got, []int64{tasks[0].ID})
+	}
+
+	// Удалённое задание пропадает из индекса
+	if err := repo.Delete(ctx, queryChatID, tasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("хлеб"); len(got) != 0 {
+		t.Errorf("search after delete = %v, want none", got)
+	}
+	if got := search("купить"); len(got) != 0 {
+		t.Errorf("search after delete = %v, want none", got)
+	}
+}
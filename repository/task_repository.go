@@ -4,16 +4,74 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/qrave1/task-track/entity"
 )
 
+var (
+	// ErrTaskNotFound задания нет в чате
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrStatusConflict статус задания изменился с момента его чтения
+	ErrStatusConflict = errors.New("task status was changed concurrently")
+	// ErrVersionConflict задание изменили с момента его чтения
+	ErrVersionConflict = errors.New("task was changed concurrently")
+)
+
 type TaskRepository interface {
 	Create(ctx context.Context, task *entity.Task) error
-	GetByID(ctx context.Context, id int64) (*entity.Task, error)
-	List(ctx context.Context, ) ([]*entity.Task, error)
+	GetByID(ctx context.Context, chatID, id int64) (*entity.Task, error)
+	List(ctx context.Context, chatID int64) ([]*entity.Task, error)
+	Query(ctx context.Context, filter TaskFilter) (TaskPage, error)
 	Update(ctx context.Context, task *entity.Task) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, chatID, id int64) error
+	ChangeStatus(ctx context.Context, task *entity.Task, to entity.TaskStatus, changedBy int64) error
+	StatusHistory(ctx context.Context, taskID int64) ([]entity.StatusChange, error)
+	LinkLegacyAssignee(ctx context.Context, chatID int64, assignee string, userID int64) (int64, error)
+	LinkLegacyUsername(ctx context.Context, chatID int64, username string, userID int64) (int64, error)
+	AdoptOrphans(ctx context.Context, chatID int64) (int64, error)
+	FlagForReassignment(ctx context.Context, chatID, assigneeID int64) (int64, error)
+	DueBefore(ctx context.Context, until time.Time) ([]*entity.Task, error)
+}
+
+const taskColumns = "id, chat_id, title, description, reward, reward_amount, assignee_id, assignee, status, needs_reassignment, due_at, series_id, auto_complete, version, created_by, created_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (*entity.Task, error) {
+	var (
+		task       entity.Task
+		assigneeID sql.NullInt64
+		dueAt      sql.NullTime
+		seriesID   sql.NullInt64
+	)
+	err := row.Scan(
+		&task.ID, &task.ChatID, &task.Title, &task.Description, &task.Reward, &task.RewardAmount, &assigneeID,
+		&task.Assignee, &task.Status, &task.NeedsReassignment, &dueAt, &seriesID, &task.AutoComplete,
+		&task.Version, &task.CreatedBy, &task.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	task.AssigneeID = assigneeID.Int64
+	task.DueAt = dueAt.Time
+	task.SeriesID = seriesID.Int64
+	return &task, nil
+}
+
+// nullID преобразует нулевой ID в NULL
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// nullTime преобразует нулевое время в NULL. Время хранится в UTC, чтобы его можно было сравнивать в запросах
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
 }
 
 // TaskRepositoryImpl Репозиторий для работы с заданиями
@@ -26,37 +84,40 @@ func NewTaskRepositoryImpl(db *sql.DB) *TaskRepositoryImpl {
 }
 
 func (r *TaskRepositoryImpl) Create(ctx context.Context, task *entity.Task) error {
-	_, err := r.db.ExecContext(
-		ctx,
-		"INSERT INTO tasks (title, description, reward, assignee, created_by) VALUES (?, ?, ?, ?, ?)",
-		task.Title, task.Description, task.Reward, task.Assignee, task.CreatedBy,
-	)
-	if err != nil {
-		return err
+	if task.Status == "" {
+		task.Status = entity.StatusOpen
 	}
-	return nil
+
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO tasks (chat_id, title, description, reward, reward_amount, assignee_id, assignee, status, due_at, series_id, auto_complete,
+			created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, version, created_at`,
+		task.ChatID, task.Title, task.Description, task.Reward, task.RewardAmount, nullID(task.AssigneeID), task.Assignee, task.Status,
+		nullTime(task.DueAt), nullID(task.SeriesID), task.AutoComplete, task.CreatedBy,
+	).Scan(&task.ID, &task.Version, &task.CreatedAt)
 }
 
-func (r *TaskRepositoryImpl) GetByID(ctx context.Context, id int64) (*entity.Task, error) {
-	var task entity.Task
-	err := r.db.QueryRowContext(
+func (r *TaskRepositoryImpl) GetByID(ctx context.Context, chatID, id int64) (*entity.Task, error) {
+	task, err := scanTask(r.db.QueryRowContext(
 		ctx,
-		"SELECT id, title, description, reward, assignee, created_by, created_at FROM tasks WHERE id = ?",
-		id,
-	).Scan(&task.ID, &task.Title, &task.Description, &task.Reward, &task.Assignee, &task.CreatedBy, &task.CreatedAt)
+		"SELECT "+taskColumns+" FROM tasks WHERE id = ? AND chat_id = ?",
+		id, chatID,
+	))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &task, nil
+	return task, nil
 }
 
-func (r *TaskRepositoryImpl) List(ctx context.Context, ) ([]*entity.Task, error) {
+func (r *TaskRepositoryImpl) List(ctx context.Context, chatID int64) ([]*entity.Task, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		"SELECT id, title, description, reward, assignee, created_by, created_at FROM tasks ORDER BY created_at DESC",
+		"SELECT "+taskColumns+" FROM tasks WHERE chat_id = ? ORDER BY created_at DESC, id DESC",
+		chatID,
 	)
 	if err != nil {
 		return nil, err
@@ -65,26 +126,220 @@ func (r *TaskRepositoryImpl) List(ctx context.Context, ) ([]*entity.Task, error)
 
 	var tasks []*entity.Task
 	for rows.Next() {
-		var task entity.Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Reward, &task.Assignee, &task.CreatedBy, &task.CreatedAt)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
-		tasks = append(tasks, &task)
+		tasks = append(tasks, task)
 	}
-	return tasks, nil
+	return tasks, rows.Err()
 }
 
+// Update сохраняет поля задания, если его версия в базе совпадает с task.Version, и увеличивает версию.
+// Возвращает ErrTaskNotFound, если задания нет, и ErrVersionConflict, если его успели изменить
 func (r *TaskRepositoryImpl) Update(ctx context.Context, task *entity.Task) error {
-	_, err := r.db.ExecContext(
+	res, err := r.db.ExecContext(
 		ctx,
-		"UPDATE tasks SET title = ?, description = ?, reward = ?, assignee = ? WHERE id = ?",
-		task.Title, task.Description, task.Reward, task.Assignee, task.ID,
+		`UPDATE tasks SET title = ?, description = ?, reward = ?, reward_amount = ?, assignee_id = ?, assignee = ?, needs_reassignment = ?, due_at = ?,
+			series_id = ?, auto_complete = ?, version = version + 1
+		WHERE id = ? AND chat_id = ? AND version = ?`,
+		task.Title, task.Description, task.Reward, task.RewardAmount, nullID(task.AssigneeID), task.Assignee, task.NeedsReassignment,
+		nullTime(task.DueAt), nullID(task.SeriesID), task.AutoComplete, task.ID, task.ChatID, task.Version,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		task.Version++
+		return nil
+	}
+
+	var exists bool
+	err = r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ? AND chat_id = ?)", task.ID, task.ChatID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTaskNotFound
+	}
+	return ErrVersionConflict
 }
 
-func (r *TaskRepositoryImpl) Delete(ctx context.Context, id int64) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = ?", id)
+func (r *TaskRepositoryImpl) Delete(ctx context.Context, chatID, id int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = ? AND chat_id = ?", id, chatID)
 	return err
 }
+
+// ChangeStatus переводит задание в новый статус и записывает переход в историю.
+// При приёмке задания в той же транзакции исполнителю начисляется награда.
+// Если статус в базе уже отличается от task.Status, возвращает ErrStatusConflict
+func (r *TaskRepositoryImpl) ChangeStatus(ctx context.Context, task *entity.Task, to entity.TaskStatus, changedBy int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE tasks SET status = ? WHERE id = ? AND chat_id = ? AND status = ?",
+		to, task.ID, task.ChatID, task.Status,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusConflict
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO task_status_history (task_id, from_status, to_status, changed_by) VALUES (?, ?, ?, ?)",
+		task.ID, task.Status, to, changedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("insert status history: %w", err)
+	}
+
+	if to == entity.StatusAccepted && task.RewardAmount > 0 && task.AssigneeID != 0 {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO ledger_entries (chat_id, user_id, amount, kind, task_id, created_by) VALUES (?, ?, ?, ?, ?, ?)",
+			task.ChatID, task.AssigneeID, task.RewardAmount, entity.LedgerTaskReward, task.ID, changedBy,
+		)
+		if err != nil {
+			return fmt.Errorf("credit task reward: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	task.Status = to
+	return nil
+}
+
+func (r *TaskRepositoryImpl) StatusHistory(ctx context.Context, taskID int64) ([]entity.StatusChange, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT task_id, from_status, to_status, changed_by, changed_at FROM task_status_history WHERE task_id = ? ORDER BY id",
+		taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []entity.StatusChange
+	for rows.Next() {
+		var change entity.StatusChange
+		err := rows.Scan(&change.TaskID, &change.From, &change.To, &change.ChangedBy, &change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+// LinkLegacyAssignee привязывает к пользователю задания чата, у которых исполнитель записан текстом assignee.
+// Возвращает количество привязанных заданий
+func (r *TaskRepositoryImpl) LinkLegacyAssignee(ctx context.Context, chatID int64, assignee string, userID int64) (int64, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE tasks SET assignee_id = ?, version = version + 1
+		WHERE chat_id = ? AND assignee_id IS NULL AND trim(assignee) = ?`,
+		userID, chatID, strings.TrimSpace(assignee),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// LinkLegacyUsername привязывает к пользователю задания чата, у которых исполнитель записан текстом @username.
+// Username в Telegram состоит из латиницы, цифр и подчёркиваний, поэтому lower() сравнивает его без учёта регистра.
+// Возвращает количество привязанных заданий
+func (r *TaskRepositoryImpl) LinkLegacyUsername(ctx context.Context, chatID int64, username string, userID int64) (int64, error) {
+	if username == "" {
+		return 0, nil
+	}
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE tasks SET assignee_id = ?, version = version + 1
+		WHERE chat_id = ? AND assignee_id IS NULL AND lower(trim(assignee)) = lower(?)`,
+		userID, chatID, "@"+username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// AdoptOrphans переносит в чат задания без чата, созданные его участниками.
+// Возвращает количество перенесённых заданий
+func (r *TaskRepositoryImpl) AdoptOrphans(ctx context.Context, chatID int64) (int64, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE tasks SET chat_id = ?, version = version + 1
+		WHERE chat_id = 0 AND created_by IN (SELECT user_id FROM chat_members WHERE chat_id = ? AND active = 1)`,
+		chatID, chatID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// FlagForReassignment отмечает незавершённые задания исполнителя как ожидающие нового исполнителя.
+// Возвращает количество заданий, отмеченных этим вызовом
+func (r *TaskRepositoryImpl) FlagForReassignment(ctx context.Context, chatID, assigneeID int64) (int64, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE tasks SET needs_reassignment = 1, version = version + 1
+		WHERE chat_id = ? AND assignee_id = ? AND status IN (?, ?) AND needs_reassignment = 0`,
+		chatID, assigneeID, entity.StatusOpen, entity.StatusInProgress,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DueBefore возвращает незавершённые задания всех активных чатов со сроком не позже until
+func (r *TaskRepositoryImpl) DueBefore(ctx context.Context, until time.Time) ([]*entity.Task, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+taskColumns+` FROM tasks
+		WHERE due_at IS NOT NULL AND due_at <= ? AND status IN (?, ?)
+			AND chat_id IN (SELECT id FROM chats WHERE active = 1)
+		ORDER BY due_at`,
+		until.UTC(), entity.StatusOpen, entity.StatusInProgress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*entity.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+// newTestChat создаёт чат для заданий теста
+func newTestChat(t *testing.T, repo *ChatRepositoryImpl, chatID int64, active bool) {
+	t.Helper()
+
+	chat := entity.NewChat(chatID)
+	chat.Active = active
+	if err := repo.Create(context.Background(), chat); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDueBeforeSkipsInactiveChats(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	tasks := NewTaskRepositoryImpl(db)
+	chats := NewChatRepositoryImpl(db)
+
+	newTestChat(t, chats, -1, true)
+	newTestChat(t, chats, -2, false)
+
+	due := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	for _, chatID := range []int64{-1, -2} {
+		task := &entity.Task{ChatID: chatID, Title: "Полить цветы", Status: entity.StatusOpen, DueAt: due, CreatedBy: 7}
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := tasks.DueBefore(ctx, due.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ChatID != -1 {
+		t.Fatalf("DueBefore returned %d tasks, want one from the active chat", len(got))
+	}
+
+	// Чат, из которого удалили бота, перестаёт получать напоминания
+	if err := chats.SetActive(ctx, -1, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tasks.DueBefore(ctx, due.Add(time.Hour)); err != nil || len(got) != 0 {
+		t.Errorf("DueBefore after deactivation = %d tasks, %v, want none", len(got), err)
+	}
+}
+
+func TestUpdateChecksVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTaskRepositoryImpl(newTestDB(t))
+
+	task := &entity.Task{ChatID: -1, Title: "Полить цветы", CreatedBy: 7}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	created := task.Version
+
+	// Успешное изменение увеличивает версию и в задании, и в базе
+	stale := *task
+	task.Title = "Полить цветы на балконе"
+	if err := repo.Update(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if task.Version != created+1 {
+		t.Errorf("version after update %d, want %d", task.Version, created+1)
+	}
+	got, err := repo.GetByID(ctx, task.ChatID, task.ID)
+	if err != nil || got == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.Version != task.Version || got.Title != task.Title {
+		t.Errorf("stored task %q v%d, want %q v%d", got.Title, got.Version, task.Title, task.Version)
+	}
+
+	// Изменение по устаревшей версии не перезаписывает чужое
+	stale.Title = "Выбросить цветы"
+	if err := repo.Update(ctx, &stale); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("stale update error = %v, want ErrVersionConflict", err)
+	}
+	if stale.Version != created {
+		t.Errorf("stale version changed to %d", stale.Version)
+	}
+	if got, _ := repo.GetByID(ctx, task.ChatID, task.ID); got == nil || got.Title != task.Title {
+		t.Errorf("stale update overwrote the task: %+v", got)
+	}
+
+	// Задание другого чата или удалённое не находится
+	other := *task
+	other.ChatID = -2
+	if err := repo.Update(ctx, &other); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("update in another chat error = %v, want ErrTaskNotFound", err)
+	}
+	if err := repo.Delete(ctx, task.ChatID, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(ctx, task); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("update of deleted task error = %v, want ErrTaskNotFound", err)
+	}
+}
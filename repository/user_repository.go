@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+type UserRepository interface {
+	Save(ctx context.Context, user entity.User) error
+	GetByID(ctx context.Context, id int64) (entity.User, error)
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]entity.User, error)
+	GetByUsername(ctx context.Context, username string) (entity.User, error)
+}
+
+const userColumns = "id, username, first_name, last_name, language_code, last_seen_at"
+
+func scanUser(row rowScanner) (entity.User, error) {
+	var (
+		user     entity.User
+		lastSeen sql.NullTime
+	)
+	err := row.Scan(&user.ID, &user.Username, &user.FirstName, &user.LastName, &user.LanguageCode, &lastSeen)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	user.LastSeenAt = lastSeen.Time
+	return user, nil
+}
+
+// UserRepositoryImpl Справочник пользователей Telegram, с которыми встречался бот
+type UserRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewUserRepositoryImpl(db *sql.DB) *UserRepositoryImpl {
+	return &UserRepositoryImpl{db: db}
+}
+
+// Save создаёт или обновляет профиль пользователя. Пустой язык и нулевое время
+// последней активности не затирают уже сохранённые значения
+func (u *UserRepositoryImpl) Save(ctx context.Context, user entity.User) error {
+	lastSeen := sql.NullTime{Time: user.LastSeenAt, Valid: !user.LastSeenAt.IsZero()}
+
+	_, err := u.db.ExecContext(
+		ctx,
+		`INSERT INTO users (id, username, first_name, last_name, language_code, last_seen_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			username = excluded.username,
+			first_name = excluded.first_name,
+			last_name = excluded.last_name,
+			language_code = COALESCE(NULLIF(excluded.language_code, ''), users.language_code),
+			last_seen_at = COALESCE(excluded.last_seen_at, users.last_seen_at),
+			updated_at = CURRENT_TIMESTAMP`,
+		user.ID, user.Username, user.FirstName, user.LastName, user.LanguageCode, lastSeen,
+	)
+	return err
+}
+
+func (u *UserRepositoryImpl) GetByID(ctx context.Context, id int64) (entity.User, error) {
+	user, err := scanUser(u.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, ErrUserNotFound
+		}
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// GetByIDs возвращает известных пользователей из списка. Неизвестные ID в результат не попадают
+func (u *UserRepositoryImpl) GetByIDs(ctx context.Context, ids []int64) (map[int64]entity.User, error) {
+	users := make(map[int64]entity.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := u.db.QueryContext(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE id IN (?"+strings.Repeat(", ?", len(ids)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users[user.ID] = user
+	}
+	return users, rows.Err()
+}
+
+// GetByUsername ищет пользователя по username без учёта регистра, ведущий @ допускается
+func (u *UserRepositoryImpl) GetByUsername(ctx context.Context, username string) (entity.User, error) {
+	username = strings.TrimPrefix(username, "@")
+	if username == "" {
+		return entity.User{}, ErrUserNotFound
+	}
+
+	user, err := scanUser(u.db.QueryRowContext(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE username = ? COLLATE NOCASE",
+		username,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, ErrUserNotFound
+		}
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"encoding/json"
+
+	"github.com/qrave1/task-track/entity"
+)
+
+type Conversation struct {
+	ChatID int64  // ID чата
+	UserID int64  // ID пользователя
+	State  string // Текущий шаг диалога
+	Data   string // Введённые значения в виде json объекта
+}
+
+func NewConversationFromEntity(c entity.Conversation) (Conversation, error) {
+	rawData, err := json.Marshal(c.Data)
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	return Conversation{
+		ChatID: c.ChatID,
+		UserID: c.UserID,
+		State:  c.State,
+		Data:   string(rawData),
+	}, nil
+}
+
+func NewEntityConversation(c Conversation) (entity.Conversation, error) {
+	data := make(map[string]string)
+	if err := json.Unmarshal([]byte(c.Data), &data); err != nil {
+		return entity.Conversation{}, err
+	}
+
+	return entity.Conversation{
+		ChatID: c.ChatID,
+		UserID: c.UserID,
+		State:  c.State,
+		Data:   data,
+	}, nil
+}